@@ -13,13 +13,8 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-func main() {
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
-	}
-
+// createAdmin creates the default admin user if it does not exist yet
+func createAdmin(cfg *config.Config) {
 	// Connect to database
 	connStr := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/api"
+	"github.com/bilbothegreedy/server-name-generator/internal/config"
+	"github.com/bilbothegreedy/server-name-generator/internal/db"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/services"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// runImport imports existing server names from a CSV or JSON file and prints
+// a per-row report. It returns 1 when any row was not accepted.
+func runImport(cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "input format, csv or json (default: from the file extension)")
+	dryRun := flags.Bool("dry-run", false, "check every row without storing anything")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	path := flags.Arg(0)
+
+	if *format == "" {
+		*format = services.ImportCSV
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			*format = services.ImportJSON
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open %s: %v\n", path, err)
+		return 1
+	}
+	defer file.Close()
+
+	rows, err := services.ParseImportRows(*format, file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", path, err)
+		return 1
+	}
+
+	logger := utils.NewLogger(cfg.LogLevel)
+	database, err := db.Connect(cfg.Database, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	nameService := api.NewNameService(cfg, database, logger)
+	report, err := nameService.ImportServerNames(context.Background(), rows, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Import failed: %v\n", err)
+		return 1
+	}
+
+	for _, row := range report.Rows {
+		if row.Status == models.ImportAccepted {
+			fmt.Printf("row %d: %s %s\n", row.Row, row.Status, row.ServerName)
+		} else {
+			fmt.Printf("row %d: %s %s: %s\n", row.Row, row.Status, row.ServerName, row.Error)
+		}
+	}
+
+	mode := ""
+	if report.DryRun {
+		mode = " (dry run, nothing stored)"
+	}
+	fmt.Printf("%d accepted, %d rejected, %d conflicts%s\n", report.Accepted, report.Rejected, report.Conflicts, mode)
+
+	if report.Rejected > 0 || report.Conflicts > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/config"
+)
+
+const usage = `Usage:
+  admin [create-admin]                       Create the default admin user
+  admin import [-format csv|json] [-dry-run] FILE
+                                             Import existing server names
+`
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	command := "create-admin"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	switch command {
+	case "create-admin":
+		createAdmin(cfg)
+	case "import":
+		os.Exit(runImport(cfg, os.Args[2:]))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
@@ -13,6 +13,10 @@ import (
 	"github.com/bilbothegreedy/server-name-generator/internal/api"
 	"github.com/bilbothegreedy/server-name-generator/internal/config"
 	"github.com/bilbothegreedy/server-name-generator/internal/db"
+	"github.com/bilbothegreedy/server-name-generator/internal/metrics"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/services"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
 	"github.com/bilbothegreedy/server-name-generator/internal/utils"
 )
 
@@ -27,6 +31,12 @@ func main() {
 	logger := utils.NewLogger(cfg.LogLevel)
 	logger.Info("Starting server name generator service")
 
+	// Set up tracing before anything opens spans.
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		logger.Fatal("Failed to set up tracing", "error", err)
+	}
+
 	// Capture application start time.
 	startTime := time.Now()
 
@@ -37,8 +47,48 @@ func main() {
 	}
 	defer database.Close()
 
+	// Initialize the name service shared by the API and background workers.
+	nameService := api.NewNameService(cfg, database, logger)
+
+	// Expose connection pool and sequence capacity metrics.
+	metrics.RegisterDBStats(database, cfg.Database.Name)
+	metrics.Registry.MustRegister(services.NewCapacityCollector(nameService, logger))
+
+	// Fan out reservation events to live event streams.
+	eventBroker := services.NewEventBroker(models.NewEventModel(database), cfg.EventStream.PollInterval, logger)
+	eventBroker.Start()
+
 	// Initialize router, now passing startTime for uptime calculation.
-	router := api.SetupRouter(cfg, database, logger, startTime)
+	router := api.SetupRouter(cfg, database, nameService, eventBroker, logger, startTime)
+
+	// Start expiring stale reservations in the background.
+	reaper := services.NewExpiryReaper(nameService, models.NewIdempotencyModel(database), cfg.Reservations.ReaperInterval, logger)
+	reaper.Start()
+
+	// Deliver queued webhooks in the background.
+	dispatcher := services.NewWebhookDispatcher(models.NewWebhookModel(database), nil, services.WebhookRetryPolicy{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		BaseDelay:   cfg.Webhooks.RetryBaseDelay,
+		MaxDelay:    cfg.Webhooks.RetryMaxDelay,
+		Timeout:     cfg.Webhooks.Timeout,
+	}, cfg.Webhooks.DispatchInterval, cfg.Webhooks.Retention, logger)
+	dispatcher.Start()
+
+	// Publish outbox events to the configured sinks.
+	var relay *services.OutboxRelay
+	if len(cfg.Outbox.Sinks) > 0 {
+		sinks, err := services.NewOutboxSinks(cfg.Outbox.Sinks, services.OutboxSinkOptions{
+			FilePath:       cfg.Outbox.FilePath,
+			WebhookURL:     cfg.Outbox.WebhookURL,
+			WebhookSecret:  cfg.Outbox.WebhookSecret,
+			WebhookTimeout: cfg.Outbox.WebhookTimeout,
+		})
+		if err != nil {
+			logger.Fatal("Failed to configure outbox sinks", "error", err)
+		}
+		relay = services.NewOutboxRelay(models.NewOutboxModel(database), sinks, cfg.Outbox.RelayInterval, cfg.Outbox.Retention, cfg.Outbox.MaxAttempts, logger)
+		relay.Start()
+	}
 
 	// Configure HTTP server.
 	srv := &http.Server{
@@ -49,6 +99,10 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Stopping the broker closes open event streams so shutdown is not held
+	// up by them.
+	srv.RegisterOnShutdown(eventBroker.Stop)
+
 	// Start server in a goroutine.
 	go func() {
 		logger.Info("Server listening", "port", cfg.Port)
@@ -70,5 +124,18 @@ func main() {
 		logger.Fatal("Server forced to shutdown", "error", err)
 	}
 
+	reaper.Stop()
+	dispatcher.Stop()
+	if relay != nil {
+		relay.Stop()
+	}
+
+	// Flush spans still waiting to be exported.
+	tracingCtx, tracingCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer tracingCancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		logger.Error("Failed to flush traces", "error", err)
+	}
+
 	logger.Info("Server exiting")
 }
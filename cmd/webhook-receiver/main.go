@@ -0,0 +1,59 @@
+// Command webhook-receiver is a local stand-in for a webhook endpoint. It
+// verifies the signature of every delivery and prints it, so webhook
+// subscriptions can be tried out without a real consumer.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/services"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	secret := flag.String("secret", "", "subscription secret used to verify signatures")
+	fail := flag.Int("fail", 0, "answer the first N deliveries with 500 to exercise retries")
+	flag.Parse()
+
+	var failures atomic.Int32
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		delivery := r.Header.Get(services.WebhookIDHeader)
+		event := r.Header.Get(services.WebhookEventHeader)
+
+		if *secret != "" {
+			err := services.VerifyWebhook(*secret,
+				r.Header.Get(services.WebhookTimestampHeader),
+				r.Header.Get(services.WebhookSignatureHeader),
+				body, 5*time.Minute, time.Now())
+			if err != nil {
+				log.Printf("delivery %s (%s): rejected: %v", delivery, event, err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if n := failures.Add(1); int(n) <= *fail {
+			log.Printf("delivery %s (%s): failing on purpose (%d/%d)", delivery, event, n, *fail)
+			http.Error(w, "simulated failure", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("delivery %s (%s): %s", delivery, event, body)
+		fmt.Fprintln(w, "ok")
+	})
+
+	log.Printf("Listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// CatalogHandler handles segment catalog HTTP requests
+type CatalogHandler struct {
+	catalogModel *models.CatalogModel
+	logger       *utils.Logger
+}
+
+// NewCatalogHandler creates a new segment catalog handler
+func NewCatalogHandler(catalogModel *models.CatalogModel, logger *utils.Logger) *CatalogHandler {
+	return &CatalogHandler{
+		catalogModel: catalogModel,
+		logger:       logger,
+	}
+}
+
+// segmentParam reads and checks the {segment} URL parameter
+func (h *CatalogHandler) segmentParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	segment := chi.URLParam(r, "segment")
+	if !models.IsCatalogSegment(segment) {
+		utils.RespondWithAppError(w, r.Context(), errors.NewNotFoundError("Unknown segment "+segment))
+		return "", false
+	}
+	return segment, true
+}
+
+// decodePayload reads and validates a catalog value payload
+func (h *CatalogHandler) decodePayload(w http.ResponseWriter, r *http.Request, payload *models.CatalogValuePayload) bool {
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		h.logger.LogError(ctx, err, "Failed to decode catalog payload")
+		utils.RespondWithAppError(w, ctx, errors.NewBadRequestError("Invalid request payload", err))
+		return false
+	}
+
+	payload.Code = strings.ToUpper(payload.Code)
+	if err := utils.Validate(*payload); err != nil {
+		h.logger.LogError(ctx, err, "Invalid catalog payload")
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return false
+	}
+
+	return true
+}
+
+// List handles GET /catalogs/{segment} requests
+func (h *CatalogHandler) List(w http.ResponseWriter, r *http.Request) {
+	segment, ok := h.segmentParam(w, r)
+	if !ok {
+		return
+	}
+
+	values, err := h.catalogModel.GetBySegment(r.Context(), segment)
+	if err != nil {
+		h.logger.Error("Failed to get catalog values", "error", err, "segment", segment)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get catalog values")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, values)
+}
+
+// Create handles POST /catalogs/{segment} requests
+func (h *CatalogHandler) Create(w http.ResponseWriter, r *http.Request) {
+	segment, ok := h.segmentParam(w, r)
+	if !ok {
+		return
+	}
+
+	var payload models.CatalogValuePayload
+	if !h.decodePayload(w, r, &payload) {
+		return
+	}
+
+	existing, err := h.catalogModel.Get(r.Context(), segment, payload.Code)
+	if err != nil {
+		h.logger.Error("Failed to check existing catalog value", "error", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create catalog value")
+		return
+	}
+	if existing != nil {
+		utils.RespondWithError(w, http.StatusConflict, "Catalog value already exists")
+		return
+	}
+
+	now := time.Now().UTC()
+	value := &models.CatalogValue{
+		Segment:     segment,
+		Code:        payload.Code,
+		Description: payload.Description,
+		Deprecated:  payload.Deprecated,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := h.catalogModel.Create(r.Context(), value); err != nil {
+		h.logger.Error("Failed to create catalog value", "error", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create catalog value")
+		return
+	}
+
+	h.logger.Info("Catalog value created", "segment", segment, "code", value.Code)
+	utils.RespondWithJSON(w, http.StatusCreated, value)
+}
+
+// Update handles PUT /catalogs/{segment}/{code} requests
+func (h *CatalogHandler) Update(w http.ResponseWriter, r *http.Request) {
+	segment, ok := h.segmentParam(w, r)
+	if !ok {
+		return
+	}
+
+	// The code comes from the URL; a code in the body must match it
+	code := strings.ToUpper(chi.URLParam(r, "code"))
+	payload := models.CatalogValuePayload{Code: code}
+	if !h.decodePayload(w, r, &payload) {
+		return
+	}
+	if payload.Code != code {
+		utils.RespondWithError(w, http.StatusBadRequest, "Catalog code cannot be changed")
+		return
+	}
+
+	value := &models.CatalogValue{
+		Segment:     segment,
+		Code:        code,
+		Description: payload.Description,
+		Deprecated:  payload.Deprecated,
+		UpdatedAt:   time.Now().UTC(),
+	}
+
+	if err := h.catalogModel.Update(r.Context(), value); err != nil {
+		h.logger.Error("Failed to update catalog value", "error", err, "segment", segment, "code", value.Code)
+		if strings.Contains(err.Error(), "not found") {
+			utils.RespondWithError(w, http.StatusNotFound, "Catalog value not found")
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update catalog value")
+		return
+	}
+
+	updated, err := h.catalogModel.Get(r.Context(), segment, value.Code)
+	if err != nil || updated == nil {
+		h.logger.Error("Failed to reload catalog value", "error", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update catalog value")
+		return
+	}
+
+	h.logger.Info("Catalog value updated", "segment", segment, "code", value.Code, "deprecated", value.Deprecated)
+	utils.RespondWithJSON(w, http.StatusOK, updated)
+}
+
+// Delete handles DELETE /catalogs/{segment}/{code} requests
+func (h *CatalogHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	segment, ok := h.segmentParam(w, r)
+	if !ok {
+		return
+	}
+
+	code := strings.ToUpper(chi.URLParam(r, "code"))
+	if err := h.catalogModel.Delete(r.Context(), segment, code); err != nil {
+		h.logger.Error("Failed to delete catalog value", "error", err, "segment", segment, "code", code)
+		if strings.Contains(err.Error(), "not found") {
+			utils.RespondWithError(w, http.StatusNotFound, "Catalog value not found")
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete catalog value")
+		return
+	}
+
+	h.logger.Info("Catalog value deleted", "segment", segment, "code", code)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Catalog value deleted successfully",
+	})
+}
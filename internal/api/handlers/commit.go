@@ -41,21 +41,10 @@ func (h *CommitHandler) Commit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Commit the reservation
-	if err := h.nameService.CommitReservation(r.Context(), payload.ReservationID); err != nil {
+	if err := h.nameService.CommitReservation(r.Context(), payload.ReservationID, payload.Tags); err != nil {
 		h.logger.Error("Failed to commit reservation", "error", err, "reservationId", payload.ReservationID)
 
-		// Check for specific error cases and return appropriate status codes
-		if err.Error() == "reservation is already committed" {
-			utils.RespondWithError(w, http.StatusConflict, "Reservation is already committed")
-			return
-		}
-
-		if err.Error() == "reservation with ID "+payload.ReservationID+" not found" {
-			utils.RespondWithError(w, http.StatusNotFound, "Reservation not found")
-			return
-		}
-
-		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to commit reservation")
+		utils.RespondWithAppError(w, r.Context(), err)
 		return
 	}
 
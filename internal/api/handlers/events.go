@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/services"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// Event stream settings
+const (
+	eventStreamBuffer    = 256
+	eventStreamHeartbeat = 15 * time.Second
+	eventStreamRetry     = 3000 // milliseconds
+)
+
+// EventHandler streams reservation events as Server-Sent Events
+type EventHandler struct {
+	broker *services.EventBroker
+	logger *utils.Logger
+}
+
+// NewEventHandler creates a new event handler
+func NewEventHandler(broker *services.EventBroker, logger *utils.Logger) *EventHandler {
+	return &EventHandler{
+		broker: broker,
+		logger: logger,
+	}
+}
+
+// parseEventFilter builds an event filter from query parameters
+func parseEventFilter(r *http.Request) (services.EventFilter, error) {
+	query := r.URL.Query()
+	filter := services.EventFilter{
+		UnitCode:    query.Get("unitCode"),
+		Type:        query.Get("type"),
+		Provider:    query.Get("provider"),
+		Region:      query.Get("region"),
+		Environment: query.Get("environment"),
+		Function:    query.Get("function"),
+	}
+
+	if status := query.Get("status"); status != "" {
+		filter.Statuses = strings.Split(status, ",")
+	}
+
+	if events := query.Get("event"); events != "" {
+		filter.Types = strings.Split(events, ",")
+		for _, eventType := range filter.Types {
+			if !models.IsEventType(eventType) {
+				return filter, fmt.Errorf("unknown event type %s", eventType)
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+// lastEventID reads the resume position from the Last-Event-ID header, or
+// the lastEventId query parameter for clients that cannot set headers
+func lastEventID(r *http.Request) (int64, bool, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("lastEventId")
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, fmt.Errorf("invalid Last-Event-ID %q", value)
+	}
+	return id, true, nil
+}
+
+// writeEvent writes a single event in the text/event-stream format
+func writeEvent(w http.ResponseWriter, e *models.ReservationEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// Stream handles GET /events/stream requests. Events committed after the
+// connection opens are pushed as they happen; a Last-Event-ID first replays
+// the stored events after that ID, or sends a reset event when more than
+// MaxEventReplay events were missed.
+func (h *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := parseEventFilter(r)
+	if err != nil {
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return
+	}
+
+	afterID, resume, err := lastEventID(r)
+	if err != nil {
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return
+	}
+
+	rc := http.NewResponseController(w)
+
+	// The stream outlives the server's write timeout.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Debug("Could not clear write deadline for event stream", "error", err)
+	}
+
+	// Subscribe before replaying so nothing committed in between is missed.
+	events, unsubscribe := h.broker.Subscribe(eventStreamBuffer)
+	defer unsubscribe()
+
+	var page []*models.ReservationEvent
+	if resume {
+		page, err = h.broker.Replay(ctx, afterID)
+		if err != nil {
+			h.logger.LogError(ctx, err, "Failed to replay reservation events")
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to replay reservation events")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventStreamRetry)
+	if err := rc.Flush(); err != nil {
+		h.logger.LogError(ctx, err, "Event stream does not support flushing")
+		return
+	}
+
+	// Live events may repeat replayed ones, including events with a lower
+	// ID that committed late, so duplicates are found by ID.
+	seen := make(map[int64]bool)
+	deliver := func(e *models.ReservationEvent) error {
+		if seen[e.ID] || !filter.Matches(e) {
+			return nil
+		}
+		if err := writeEvent(w, e); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	// Page through the stored events until the replay reaches the newest
+	// one. Live events arriving meanwhile are held back until it has.
+	var pending []*models.ReservationEvent
+	replayed := 0
+	for len(page) > 0 {
+		for _, e := range page {
+			if err := deliver(e); err != nil {
+				return
+			}
+			seen[e.ID] = true
+			afterID = e.ID
+		}
+		replayed += len(page)
+		if len(page) < services.EventReplayPageSize {
+			break
+		}
+
+		for drained := false; !drained; {
+			select {
+			case e, ok := <-events:
+				if !ok {
+					// Dropped while replaying; the client resumes from the
+					// last replayed event.
+					return
+				}
+				pending = append(pending, e)
+			default:
+				drained = true
+			}
+		}
+
+		if replayed >= services.MaxEventReplay {
+			// Too far behind to replay: tell the client to reload its state
+			// and continue from the newest event.
+			latest, err := h.broker.LatestID(ctx)
+			if err != nil {
+				h.logger.LogError(ctx, err, "Failed to get latest reservation event")
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: reset\ndata: {\"lastEventId\":%d}\n\n", latest, latest); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			break
+		}
+
+		page, err = h.broker.Replay(ctx, afterID)
+		if err != nil {
+			h.logger.LogError(ctx, err, "Failed to replay reservation events")
+			return
+		}
+	}
+
+	for _, e := range pending {
+		if err := deliver(e); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case e, ok := <-events:
+			if !ok {
+				// Closed when the subscriber fell behind or the server is
+				// shutting down; the client reconnects with Last-Event-ID.
+				return
+			}
+			if err := deliver(e); err != nil {
+				return
+			}
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
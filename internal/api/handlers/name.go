@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/services"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// NameHandler handles HTTP requests about individual server names
+type NameHandler struct {
+	nameService *services.NameGeneratorService
+	logger      *utils.Logger
+}
+
+// NewNameHandler creates a new server name handler
+func NewNameHandler(nameService *services.NameGeneratorService, logger *utils.Logger) *NameHandler {
+	return &NameHandler{
+		nameService: nameService,
+		logger:      logger,
+	}
+}
+
+// Get handles GET /names/{name} requests
+func (h *NameHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "name")
+
+	reservation, err := h.nameService.GetReservationByName(ctx, name)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to look up server name")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, reservation)
+}
+
+// Decode handles GET /names/{name}/decode requests
+func (h *NameHandler) Decode(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "name")
+
+	decoded, err := h.nameService.DecodeServerName(ctx, name)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to decode server name")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, decoded)
+}
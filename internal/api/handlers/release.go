@@ -55,18 +55,7 @@ func (h *ReleaseHandler) Release(w http.ResponseWriter, r *http.Request) {
 	if err := h.nameService.ReleaseReservation(r.Context(), payload.ReservationID); err != nil {
 		h.logger.Error("Failed to release reservation", "error", err, "reservationId", payload.ReservationID)
 
-		// Check for specific error cases and return appropriate status codes
-		if err.Error() == "reservation is not committed" {
-			utils.RespondWithError(w, http.StatusBadRequest, "Reservation is not committed")
-			return
-		}
-
-		if err.Error() == "reservation with ID "+payload.ReservationID+" not found" {
-			utils.RespondWithError(w, http.StatusNotFound, "Reservation not found")
-			return
-		}
-
-		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to release reservation")
+		utils.RespondWithAppError(w, r.Context(), err)
 		return
 	}
 
@@ -2,13 +2,20 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	custommw "github.com/bilbothegreedy/server-name-generator/internal/api/middleware"
 	"github.com/bilbothegreedy/server-name-generator/internal/errors"
 	"github.com/bilbothegreedy/server-name-generator/internal/models"
 	"github.com/bilbothegreedy/server-name-generator/internal/services"
 	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+	"github.com/go-chi/chi/v5"
 )
 
 // ReservationHandler handles reservation-related HTTP requests
@@ -52,11 +59,11 @@ func (h *ReservationHandler) Reserve(w http.ResponseWriter, r *http.Request) {
 		h.logger.LogError(ctx, err, "Failed to reserve server name")
 
 		// Convert error to appropriate type
-		var appErr *errors.AppError
-		if strings.Contains(err.Error(), "already in use") {
-			appErr = errors.NewConflictError("Server name is already in use")
-		} else {
+		appErr, ok := err.(*errors.AppError)
+		if !ok {
 			appErr = errors.NewInternalError("Failed to reserve server name", err)
+		} else if strings.Contains(err.Error(), "already in use") {
+			appErr = errors.NewConflictError("Server name is already in use")
 		}
 
 		utils.RespondWithAppError(w, ctx, appErr)
@@ -71,3 +78,419 @@ func (h *ReservationHandler) Reserve(w http.ResponseWriter, r *http.Request) {
 	// Respond with reservation details
 	utils.RespondWithJSON(w, http.StatusCreated, result)
 }
+
+// List handles GET /reservations requests. Results are paginated unless
+// all=true is given, in which case every matching reservation is returned as
+// a plain array. See parseReservationFilter for the supported filters.
+func (h *ReservationHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	filter, err := parseReservationFilter(query)
+	if err != nil {
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return
+	}
+
+	if query.Get("all") == "true" {
+		reservations, err := h.nameService.FindReservations(ctx, filter)
+		if err != nil {
+			h.logger.LogError(ctx, err, "Failed to get reservations")
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get reservations")
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, reservations)
+		return
+	}
+
+	page := models.PageRequest{
+		Sort:       query.Get("sort"),
+		Descending: query.Get("order") != "asc",
+		Cursor:     query.Get("cursor"),
+	}
+	if order := query.Get("order"); order != "" && order != "asc" && order != "desc" {
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError("order must be asc or desc", nil))
+		return
+	}
+	if limit := query.Get("limit"); limit != "" {
+		page.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			utils.RespondWithAppError(w, ctx, errors.NewValidationError("limit must be a number", err))
+			return
+		}
+	}
+
+	result, err := h.nameService.ListReservations(ctx, filter, page)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to get reservations")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, result)
+}
+
+// Export handles GET /reservations/export requests. The format query
+// parameter is csv, ndjson or yaml, and the list filters apply. Rows are
+// streamed, so an error after the headers aborts the connection.
+func (h *ReservationHandler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	// Large exports outlive the server's write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Debug("Could not clear write deadline for export", "error", err)
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = services.ExportCSV
+	}
+	contentType, ok := services.ExportContentTypes[format]
+	if !ok {
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError("format must be csv, ndjson or yaml", nil))
+		return
+	}
+
+	filter, err := parseReservationFilter(query)
+	if err != nil {
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return
+	}
+
+	filename := fmt.Sprintf("reservations-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	count, err := h.nameService.ExportReservations(ctx, filter, format, w)
+	if err != nil {
+		// The status line is already sent, so abort the connection to
+		// leave the client with a visibly incomplete transfer instead of
+		// a truncated file that looks complete.
+		h.logger.LogError(ctx, err, "Reservation export failed")
+		panic(http.ErrAbortHandler)
+	}
+
+	h.logger.Info("Reservations exported", "format", format, "count", count)
+}
+
+// Import handles POST /reservations/import requests. The body is CSV or a
+// JSON array of rows, picked by the format query parameter or else by the
+// Content-Type header. With dryRun=true nothing is stored.
+func (h *ReservationHandler) Import(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = services.ImportJSON
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+			format = services.ImportCSV
+		}
+	}
+
+	rows, err := services.ParseImportRows(format, r.Body)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to parse import")
+		utils.RespondWithAppError(w, ctx, errors.NewBadRequestError("Invalid import: "+err.Error(), err))
+		return
+	}
+
+	report, err := h.nameService.ImportServerNames(ctx, rows, query.Get("dryRun") == "true")
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to import server names")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, report)
+}
+
+// parseReservationFilter reads reservation filters from query parameters:
+// createdBy, committedBy, apiKeyId, ticket and requestedFor; status as a
+// comma-separated list; the segments unitCode, type, provider, region,
+// environment and function; createdAfter, createdBefore, updatedAfter and
+// updatedBefore as RFC 3339 times; namePrefix and nameContains; and a tag
+// selector such as tags=app=billing,env!=dev.
+func parseReservationFilter(query url.Values) (models.ReservationFilter, error) {
+	filter := models.ReservationFilter{
+		CreatedBy:    query.Get("createdBy"),
+		CommittedBy:  query.Get("committedBy"),
+		APIKeyID:     query.Get("apiKeyId"),
+		Ticket:       query.Get("ticket"),
+		RequestedFor: query.Get("requestedFor"),
+		UnitCode:     query.Get("unitCode"),
+		Type:         query.Get("type"),
+		Provider:     query.Get("provider"),
+		Region:       query.Get("region"),
+		Environment:  query.Get("environment"),
+		Function:     query.Get("function"),
+		NamePrefix:   query.Get("namePrefix"),
+		NameContains: query.Get("nameContains"),
+	}
+
+	if status := query.Get("status"); status != "" {
+		filter.Statuses = strings.Split(status, ",")
+	}
+
+	times := map[string]**time.Time{
+		"createdAfter":  &filter.CreatedAfter,
+		"createdBefore": &filter.CreatedBefore,
+		"updatedAfter":  &filter.UpdatedAfter,
+		"updatedBefore": &filter.UpdatedBefore,
+	}
+	for param, field := range times {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 time", param)
+		}
+		*field = &t
+	}
+
+	if selector := query.Get("tags"); selector != "" {
+		selectors, err := models.ParseTagSelectors(selector)
+		if err != nil {
+			return filter, fmt.Errorf("invalid tag selector: %w", err)
+		}
+		filter.Tags = selectors
+	}
+
+	return filter, nil
+}
+
+// Get handles GET /reservations/{id} requests
+func (h *ReservationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	reservation, err := h.nameService.GetReservation(ctx, id)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to get reservation")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, reservation)
+}
+
+// UpdateTags handles PATCH /reservations/{id}/tags requests. Only the user
+// who created the reservation, or an admin, may edit its tags.
+func (h *ReservationHandler) UpdateTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	var patch models.TagsPatchPayload
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		h.logger.LogError(ctx, err, "Failed to decode tags payload")
+		utils.RespondWithAppError(w, ctx, errors.NewBadRequestError("Invalid request payload", err))
+		return
+	}
+
+	existing, err := h.nameService.GetReservation(ctx, id)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to get reservation")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	// Check if the caller owns the reservation or is an admin
+	principal, _ := utils.PrincipalFromContext(ctx)
+	claims, ok := custommw.GetUserClaims(r)
+	isAdmin := ok && claims.Role == models.RoleAdmin
+	if !isAdmin && (principal.UserID == "" || existing.CreatedBy != principal.UserID) {
+		utils.RespondWithAppError(w, ctx, errors.NewForbiddenError("You don't have permission to edit this reservation's tags"))
+		return
+	}
+
+	reservation, err := h.nameService.UpdateReservationTags(ctx, id, patch)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to update reservation tags")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	h.logger.Info("Reservation tags updated", "id", id, "tags", len(reservation.Tags))
+	utils.RespondWithJSON(w, http.StatusOK, reservation)
+}
+
+// History handles GET /reservations/{id}/history requests
+func (h *ReservationHandler) History(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	events, err := h.nameService.GetReservationHistory(ctx, id)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to get reservation history")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, events)
+}
+
+// Decommission handles POST /reservations/{id}/decommission requests. The
+// body is optional and may override the quarantine period.
+func (h *ReservationHandler) Decommission(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	// An empty body, chunked or not, decodes to io.EOF
+	var payload models.DecommissionPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && err != io.EOF {
+		h.logger.LogError(ctx, err, "Failed to decode decommission payload")
+		utils.RespondWithAppError(w, ctx, errors.NewBadRequestError("Invalid request payload", err))
+		return
+	}
+	if err := utils.Validate(payload); err != nil {
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return
+	}
+
+	quarantine := time.Duration(payload.QuarantineSeconds) * time.Second
+	if err := h.nameService.DecommissionReservation(ctx, id, quarantine); err != nil {
+		h.logger.LogError(ctx, err, "Failed to decommission reservation")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Reservation decommissioned successfully",
+	})
+}
+
+// Recommission handles POST /reservations/{id}/recommission requests
+func (h *ReservationHandler) Recommission(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	if err := h.nameService.RecommissionReservation(ctx, id); err != nil {
+		h.logger.LogError(ctx, err, "Failed to recommission reservation")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Reservation recommissioned successfully",
+	})
+}
+
+// Retire handles POST /reservations/{id}/retire requests
+func (h *ReservationHandler) Retire(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	if err := h.nameService.RetireReservation(ctx, id); err != nil {
+		h.logger.LogError(ctx, err, "Failed to retire reservation")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Reservation retired successfully",
+	})
+}
+
+// Preview handles POST /reserve/preview requests
+func (h *ReservationHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var payload models.ReservationPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.logger.LogError(ctx, err, "Failed to decode request body")
+		utils.RespondWithAppError(w, ctx, errors.NewBadRequestError("Invalid request payload", err))
+		return
+	}
+
+	if err := utils.Validate(payload); err != nil {
+		h.logger.LogError(ctx, err, "Invalid preview payload")
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return
+	}
+
+	result, err := h.nameService.PreviewServerName(ctx, payload)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to preview server name")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, result)
+}
+
+// ReserveExplicit handles POST /reserve/explicit requests
+func (h *ReservationHandler) ReserveExplicit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var payload models.ExplicitReservationPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.logger.LogError(ctx, err, "Failed to decode request body")
+		utils.RespondWithAppError(w, ctx, errors.NewBadRequestError("Invalid request payload", err))
+		return
+	}
+
+	if err := utils.Validate(payload); err != nil {
+		h.logger.LogError(ctx, err, "Invalid explicit reservation payload")
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return
+	}
+
+	result, err := h.nameService.ClaimServerName(ctx, payload)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to claim server name")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	h.logger.Info("Server name claimed",
+		"reservationId", result.ReservationID,
+		"serverName", result.ServerName,
+	)
+
+	utils.RespondWithJSON(w, http.StatusCreated, result)
+}
+
+// ReserveBatch handles POST /reserve/batch requests
+func (h *ReservationHandler) ReserveBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var payload models.BatchReservationPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.logger.LogError(ctx, err, "Failed to decode request body")
+		utils.RespondWithAppError(w, ctx, errors.NewBadRequestError("Invalid request payload", err))
+		return
+	}
+
+	if err := utils.Validate(payload); err != nil {
+		h.logger.LogError(ctx, err, "Invalid batch reservation payload")
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return
+	}
+
+	// Exactly one of payload+count or reservations must be given
+	templated := payload.Payload != nil && payload.Count > 0 && len(payload.Reservations) == 0
+	listed := payload.Payload == nil && payload.Count == 0 && len(payload.Reservations) > 0
+	if !templated && !listed {
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(
+			"Provide either payload and count, or a list of reservations", nil))
+		return
+	}
+
+	results, err := h.nameService.ReserveServerNames(ctx, payload.Expand())
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to reserve server names")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	h.logger.Info("Server names reserved", "count", len(results))
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"reservations": results,
+	})
+}
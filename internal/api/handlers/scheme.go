@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/services"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// SchemeHandler handles naming scheme HTTP requests
+type SchemeHandler struct {
+	nameService *services.NameGeneratorService
+	schemeModel *models.NamingSchemeModel
+	logger      *utils.Logger
+}
+
+// NewSchemeHandler creates a new naming scheme handler
+func NewSchemeHandler(nameService *services.NameGeneratorService, schemeModel *models.NamingSchemeModel, logger *utils.Logger) *SchemeHandler {
+	return &SchemeHandler{
+		nameService: nameService,
+		schemeModel: schemeModel,
+		logger:      logger,
+	}
+}
+
+// GetAll handles GET /schemes requests
+func (h *SchemeHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	schemes, err := h.schemeModel.GetAll(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to get naming schemes", "error", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get naming schemes")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, schemes)
+}
+
+// Get handles GET /schemes/{id} requests
+func (h *SchemeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing scheme ID")
+		return
+	}
+
+	scheme, err := h.schemeModel.GetByID(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get naming scheme", "error", err, "id", id)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get naming scheme")
+		return
+	}
+
+	if scheme == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Naming scheme not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, scheme)
+}
+
+// Create handles POST /schemes requests. Posting an existing name creates a new version.
+func (h *SchemeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var payload models.NamingSchemePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.logger.LogError(ctx, err, "Failed to decode naming scheme payload")
+		utils.RespondWithAppError(w, ctx, errors.NewBadRequestError("Invalid request payload", err))
+		return
+	}
+
+	if err := utils.Validate(payload); err != nil {
+		h.logger.LogError(ctx, err, "Invalid naming scheme payload")
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return
+	}
+
+	scheme, err := h.nameService.CreateNamingScheme(ctx, payload)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to create naming scheme")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, scheme)
+}
+
+// Deactivate handles DELETE /schemes/{id} requests
+func (h *SchemeHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing scheme ID")
+		return
+	}
+
+	if err := h.schemeModel.Deactivate(r.Context(), id); err != nil {
+		h.logger.Error("Failed to deactivate naming scheme", "error", err, "id", id)
+		if strings.Contains(err.Error(), "not found") {
+			utils.RespondWithError(w, http.StatusNotFound, "Naming scheme not found")
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to deactivate naming scheme")
+		return
+	}
+
+	h.logger.Info("Naming scheme deactivated", "id", id)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Naming scheme deactivated successfully",
+	})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/services"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// SequenceHandler handles sequence counter HTTP requests
+type SequenceHandler struct {
+	nameService *services.NameGeneratorService
+	logger      *utils.Logger
+}
+
+// NewSequenceHandler creates a new sequence counter handler
+func NewSequenceHandler(nameService *services.NameGeneratorService, logger *utils.Logger) *SequenceHandler {
+	return &SequenceHandler{
+		nameService: nameService,
+		logger:      logger,
+	}
+}
+
+// decodePayload reads and validates a sequence payload
+func (h *SequenceHandler) decodePayload(w http.ResponseWriter, r *http.Request) (*models.SequencePayload, bool) {
+	ctx := r.Context()
+
+	var payload models.SequencePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.logger.LogError(ctx, err, "Failed to decode sequence payload")
+		utils.RespondWithAppError(w, ctx, errors.NewBadRequestError("Invalid request payload", err))
+		return nil, false
+	}
+
+	if err := utils.Validate(payload); err != nil {
+		h.logger.LogError(ctx, err, "Invalid sequence payload")
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return nil, false
+	}
+
+	return &payload, true
+}
+
+// GetAll handles GET /sequences requests
+func (h *SequenceHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	sequences, err := h.nameService.GetSequences(r.Context())
+	if err != nil {
+		h.logger.LogError(r.Context(), err, "Failed to get sequences")
+		utils.RespondWithAppError(w, r.Context(), err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, sequences)
+}
+
+// Seed handles PUT /sequences requests
+func (h *SequenceHandler) Seed(w http.ResponseWriter, r *http.Request) {
+	payload, ok := h.decodePayload(w, r)
+	if !ok {
+		return
+	}
+
+	value, err := h.nameService.SeedSequence(r.Context(), payload.SequenceKey, payload.Value)
+	if err != nil {
+		h.logger.LogError(r.Context(), err, "Failed to seed sequence")
+		utils.RespondWithAppError(w, r.Context(), err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]int{"currentValue": value})
+}
+
+// Reset handles POST /sequences/reset requests
+func (h *SequenceHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	payload, ok := h.decodePayload(w, r)
+	if !ok {
+		return
+	}
+
+	value, err := h.nameService.ResetSequence(r.Context(), payload.SequenceKey)
+	if err != nil {
+		h.logger.LogError(r.Context(), err, "Failed to reset sequence")
+		utils.RespondWithAppError(w, r.Context(), err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]int{"currentValue": value})
+}
+
+// UpdateSettings handles PUT /sequences/settings requests
+func (h *SequenceHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var payload models.SequenceSettingsPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.logger.LogError(ctx, err, "Failed to decode sequence settings payload")
+		utils.RespondWithAppError(w, ctx, errors.NewBadRequestError("Invalid request payload", err))
+		return
+	}
+
+	if err := utils.Validate(payload); err != nil {
+		h.logger.LogError(ctx, err, "Invalid sequence settings payload")
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return
+	}
+
+	err := h.nameService.UpdateSequenceSettings(ctx, payload.SequenceKey,
+		payload.OverflowPolicy, payload.AllocationMode, payload.ReuseCooldownSeconds)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to update sequence settings")
+		utils.RespondWithAppError(w, ctx, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Sequence settings updated successfully",
+	})
+}
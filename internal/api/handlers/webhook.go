@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxDeadLetters is the most dead letters returned by one request
+const maxDeadLetters = 500
+
+// WebhookHandler handles webhook subscription HTTP requests
+type WebhookHandler struct {
+	webhookModel *models.WebhookModel
+	logger       *utils.Logger
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookModel *models.WebhookModel, logger *utils.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookModel: webhookModel,
+		logger:       logger,
+	}
+}
+
+// decodePayload reads and validates a webhook payload
+func (h *WebhookHandler) decodePayload(w http.ResponseWriter, r *http.Request, payload *models.WebhookPayload) bool {
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		h.logger.LogError(ctx, err, "Failed to decode webhook payload")
+		utils.RespondWithAppError(w, ctx, errors.NewBadRequestError("Invalid request payload", err))
+		return false
+	}
+
+	if err := utils.Validate(*payload); err != nil {
+		h.logger.LogError(ctx, err, "Invalid webhook payload")
+		utils.RespondWithAppError(w, ctx, errors.NewValidationError(err.Error(), err))
+		return false
+	}
+
+	for _, eventType := range payload.EventTypes {
+		if !models.IsEventType(eventType) {
+			utils.RespondWithAppError(w, ctx, errors.NewValidationError("Unknown event type "+eventType, nil))
+			return false
+		}
+	}
+
+	return true
+}
+
+// subscription loads the subscription named by the {id} URL parameter
+func (h *WebhookHandler) subscription(w http.ResponseWriter, r *http.Request) (*models.WebhookSubscription, bool) {
+	ctx := r.Context()
+	id := chi.URLParam(r, "id")
+
+	notFound := errors.NewNotFoundError("Webhook subscription " + id + " not found")
+	if _, err := uuid.Parse(id); err != nil {
+		utils.RespondWithAppError(w, ctx, notFound)
+		return nil, false
+	}
+
+	subscription, err := h.webhookModel.GetByID(ctx, id)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to get webhook subscription")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get webhook subscription")
+		return nil, false
+	}
+	if subscription == nil {
+		utils.RespondWithAppError(w, ctx, notFound)
+		return nil, false
+	}
+
+	return subscription, true
+}
+
+// List handles GET /webhooks requests
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.webhookModel.GetAll(r.Context())
+	if err != nil {
+		h.logger.LogError(r.Context(), err, "Failed to get webhook subscriptions")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get webhook subscriptions")
+		return
+	}
+
+	// Secrets are only shown when a subscription is created
+	for _, s := range subscriptions {
+		s.Secret = ""
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, subscriptions)
+}
+
+// Get handles GET /webhooks/{id} requests
+func (h *WebhookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+
+	subscription.Secret = ""
+	utils.RespondWithJSON(w, http.StatusOK, subscription)
+}
+
+// Create handles POST /webhooks requests. The response includes the signing
+// secret, which is not shown again.
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var payload models.WebhookPayload
+	if !h.decodePayload(w, r, &payload) {
+		return
+	}
+
+	now := time.Now().UTC()
+	subscription := &models.WebhookSubscription{
+		ID:          uuid.New().String(),
+		URL:         payload.URL,
+		Secret:      payload.Secret,
+		EventTypes:  payload.EventTypes,
+		Description: payload.Description,
+		Active:      payload.Active == nil || *payload.Active,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if subscription.Secret == "" {
+		subscription.Secret = models.GenerateWebhookSecret()
+	}
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+	if principal, ok := utils.PrincipalFromContext(ctx); ok {
+		subscription.CreatedBy = principal.UserID
+	}
+
+	if err := h.webhookModel.Create(ctx, subscription); err != nil {
+		h.logger.LogError(ctx, err, "Failed to create webhook subscription")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create webhook subscription")
+		return
+	}
+
+	h.logger.Info("Webhook subscription created", "id", subscription.ID, "url", subscription.URL)
+	utils.RespondWithJSON(w, http.StatusCreated, subscription)
+}
+
+// Update handles PUT /webhooks/{id} requests. The secret is kept when the
+// payload does not give a new one.
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	subscription, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+
+	var payload models.WebhookPayload
+	if !h.decodePayload(w, r, &payload) {
+		return
+	}
+
+	subscription.URL = payload.URL
+	subscription.EventTypes = payload.EventTypes
+	subscription.Description = payload.Description
+	subscription.UpdatedAt = time.Now().UTC()
+	if payload.Secret != "" {
+		subscription.Secret = payload.Secret
+	}
+	if payload.Active != nil {
+		subscription.Active = *payload.Active
+	}
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+
+	updated, err := h.webhookModel.Update(ctx, subscription)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to update webhook subscription")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update webhook subscription")
+		return
+	}
+	if !updated {
+		utils.RespondWithAppError(w, ctx, errors.NewNotFoundError("Webhook subscription "+subscription.ID+" not found"))
+		return
+	}
+
+	h.logger.Info("Webhook subscription updated", "id", subscription.ID, "active", subscription.Active)
+	subscription.Secret = ""
+	utils.RespondWithJSON(w, http.StatusOK, subscription)
+}
+
+// Delete handles DELETE /webhooks/{id} requests. Queued deliveries and dead
+// letters of the subscription are removed with it.
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	subscription, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.webhookModel.Delete(ctx, subscription.ID); err != nil {
+		h.logger.LogError(ctx, err, "Failed to delete webhook subscription")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete webhook subscription")
+		return
+	}
+
+	h.logger.Info("Webhook subscription deleted", "id", subscription.ID)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Webhook subscription deleted successfully",
+	})
+}
+
+// Ping handles POST /webhooks/{id}/ping requests by queueing a test delivery
+func (h *WebhookHandler) Ping(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	subscription, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":           models.EventPing,
+		"subscriptionId": subscription.ID,
+		"createdAt":      now,
+	})
+
+	delivery, err := h.webhookModel.EnqueuePing(ctx, subscription.ID, payload, now)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to queue webhook ping")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to queue webhook ping")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusAccepted, delivery)
+}
+
+// DeadLetters handles GET /webhooks/dead-letters requests, optionally
+// filtered by the subscriptionId query parameter
+func (h *WebhookHandler) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	deliveries, err := h.webhookModel.GetDeadLetters(ctx, r.URL.Query().Get("subscriptionId"), maxDeadLetters)
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to get dead letters")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get dead letters")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, deliveries)
+}
+
+// Redeliver handles POST /webhooks/dead-letters/{deliveryId}/retry requests
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	param := chi.URLParam(r, "deliveryId")
+
+	notFound := errors.NewNotFoundError("Dead letter " + param + " not found")
+	id, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		utils.RespondWithAppError(w, ctx, notFound)
+		return
+	}
+
+	requeued, err := h.webhookModel.Redeliver(ctx, id, time.Now().UTC())
+	if err != nil {
+		h.logger.LogError(ctx, err, "Failed to redeliver webhook")
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to redeliver webhook")
+		return
+	}
+	if !requeued {
+		utils.RespondWithAppError(w, ctx, notFound)
+		return
+	}
+
+	h.logger.Info("Dead letter queued for redelivery", "deliveryId", id)
+	utils.RespondWithJSON(w, http.StatusAccepted, map[string]string{
+		"message": "Delivery queued for retry",
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/bilbothegreedy/server-name-generator/internal/auth"
+	"github.com/bilbothegreedy/server-name-generator/internal/metrics"
 	"github.com/bilbothegreedy/server-name-generator/internal/models"
 	"github.com/bilbothegreedy/server-name-generator/internal/utils"
 )
@@ -41,12 +42,14 @@ func APIKeyAuthenticate(apiKeyModel *models.APIKeyModel, logger *utils.Logger) f
 			key, err := apiKeyModel.GetByKey(r.Context(), apiKey)
 			if err != nil {
 				logger.Error("Failed to verify API key", "error", err)
+				metrics.RecordAuthFailure(metrics.AuthFailureLookup)
 				utils.RespondWithError(w, http.StatusUnauthorized, "Invalid API key")
 				return
 			}
 
 			if key == nil || !key.IsActive {
 				logger.Info("Invalid or inactive API key used")
+				metrics.RecordAuthFailure(metrics.AuthFailureInvalidKey)
 				utils.RespondWithError(w, http.StatusUnauthorized, "Invalid API key")
 				return
 			}
@@ -59,8 +62,9 @@ func APIKeyAuthenticate(apiKeyModel *models.APIKeyModel, logger *utils.Logger) f
 				IsActive: key.IsActive,
 			}
 
-			// Add claims to request context
+			// Add claims and principal to request context
 			ctx := context.WithValue(r.Context(), APIKeyClaimsKey, claims)
+			ctx = utils.WithPrincipal(ctx, utils.Principal{UserID: key.UserID, APIKeyID: key.ID})
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -81,6 +85,7 @@ func RequireAPIKeyScope(scopes ...string) func(http.Handler) http.Handler {
 
 			// Check if API key has required scopes
 			if !hasScope(claims.Scopes, scopes) {
+				metrics.RecordAuthFailure(metrics.AuthFailureMissingScope)
 				utils.RespondWithError(w, http.StatusForbidden, "API key missing required scope")
 				return
 			}
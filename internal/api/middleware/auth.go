@@ -45,8 +45,9 @@ func Authenticate(jwtManager *auth.JWTManager, logger *utils.Logger) func(http.H
 				return
 			}
 
-			// Add claims to request context
+			// Add claims and principal to request context
 			ctx := context.WithValue(r.Context(), UserClaimsKey, claims)
+			ctx = utils.WithPrincipal(ctx, utils.Principal{UserID: claims.ID})
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -119,8 +120,9 @@ func OptionalAuth(jwtManager *auth.JWTManager, logger *utils.Logger) func(http.H
 				return
 			}
 
-			// Add claims to request context
+			// Add claims and principal to request context
 			ctx := context.WithValue(r.Context(), UserClaimsKey, claims)
+			ctx = utils.WithPrincipal(ctx, utils.Principal{UserID: claims.ID})
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -7,7 +7,10 @@ import (
 	"runtime/debug"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+
 	apperrors "github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/metrics"
 	"github.com/bilbothegreedy/server-name-generator/internal/utils"
 )
 
@@ -17,6 +20,13 @@ func ErrorHandler(logger *utils.Logger) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if rec := recover(); rec != nil {
+					// Handlers abort a response they have already started
+					// with http.ErrAbortHandler; let the server drop the
+					// connection instead of appending an error body.
+					if rec == http.ErrAbortHandler {
+						panic(rec)
+					}
+
 					// Get stack trace
 					stack := debug.Stack()
 
@@ -61,7 +71,8 @@ func ErrorHandler(logger *utils.Logger) func(http.Handler) http.Handler {
 	}
 }
 
-// RequestLogger logs each request and its response
+// RequestLogger logs each request and its response and records it in the
+// HTTP metrics by route pattern
 func RequestLogger(logger *utils.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -92,6 +103,12 @@ func RequestLogger(logger *utils.Logger) func(http.Handler) http.Handler {
 				rw.StatusCode,
 				duration,
 			)
+
+			var route string
+			if rctx := chi.RouteContext(ctx); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+			metrics.ObserveHTTPRequest(r.Method, route, rw.StatusCode, duration)
 		})
 	}
 }
@@ -134,3 +151,20 @@ func TimeoutMiddleware(logger *utils.Logger, timeout int) func(http.Handler) htt
 		})
 	}
 }
+
+// SkipForPaths applies mw to every request except those for the given paths,
+// such as long-lived streams that must outlive request timeouts
+func SkipForPaths(mw func(http.Handler) http.Handler, paths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, p := range paths {
+				if r.URL.Path == p {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
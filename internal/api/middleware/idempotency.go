@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// IdempotencyKeyHeader is the request header that makes a mutating request replayable
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the length of client supplied keys
+const maxIdempotencyKeyLength = 255
+
+// idempotencyRecorder captures the status and body written by the handler
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *idempotencyRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Idempotency middleware honours the Idempotency-Key header. The first request
+// with a key is executed and its response stored; replays within ttl receive
+// the stored response, and reusing a key with a different request body is
+// rejected with 422. Keys are scoped to the authenticated user. Server errors
+// are not stored so the request can be retried.
+func Idempotency(idempotencyModel *models.IdempotencyModel, ttl time.Duration, logger *utils.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				utils.RespondWithError(w, http.StatusBadRequest, "Idempotency-Key is too long")
+				return
+			}
+
+			ctx := r.Context()
+
+			scope := ""
+			if principal, ok := utils.PrincipalFromContext(ctx); ok {
+				scope = principal.UserID
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			r.Body.Close()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.New()
+			hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+			hash.Write(body)
+			requestHash := hex.EncodeToString(hash.Sum(nil))
+
+			now := time.Now().UTC()
+			claimed, err := idempotencyModel.Begin(ctx, &models.IdempotencyRecord{
+				Scope:       scope,
+				Key:         key,
+				RequestHash: requestHash,
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(ttl),
+			})
+			if err != nil {
+				logger.Error("Failed to claim idempotency key", "error", err)
+				utils.RespondWithError(w, http.StatusInternalServerError, "Failed to process idempotency key")
+				return
+			}
+
+			if !claimed {
+				rec, err := idempotencyModel.Get(ctx, scope, key)
+				if err != nil || rec == nil {
+					logger.Error("Failed to load idempotency key", "error", err)
+					utils.RespondWithError(w, http.StatusInternalServerError, "Failed to process idempotency key")
+					return
+				}
+
+				switch {
+				case rec.RequestHash != requestHash:
+					utils.RespondWithError(w, http.StatusUnprocessableEntity,
+						"Idempotency-Key was already used with a different request")
+				case rec.StatusCode == 0:
+					utils.RespondWithError(w, http.StatusConflict,
+						"A request with this Idempotency-Key is still in progress")
+				default:
+					logger.Debug("Replaying idempotent response", "key", key, "status", rec.StatusCode)
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(rec.StatusCode)
+					w.Write(rec.ResponseBody)
+				}
+				return
+			}
+
+			recorder := &idempotencyRecorder{ResponseWriter: w}
+
+			// Settle the key even when the client disconnected, the request
+			// timed out or the handler panicked. Otherwise every retry would
+			// be told the request is still in progress until the key expires.
+			defer func() {
+				settleCtx := context.WithoutCancel(ctx)
+				panicked := recover()
+
+				// Let the client retry requests that failed on our side
+				if panicked != nil || recorder.status == 0 || recorder.status >= http.StatusInternalServerError {
+					if err := idempotencyModel.Delete(settleCtx, scope, key); err != nil {
+						logger.Error("Failed to release idempotency key", "error", err)
+					}
+					if panicked != nil {
+						panic(panicked)
+					}
+					return
+				}
+
+				if err := idempotencyModel.Complete(settleCtx, scope, key, recorder.status, recorder.body.Bytes()); err != nil {
+					logger.Error("Failed to store idempotent response", "error", err)
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
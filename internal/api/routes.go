@@ -3,7 +3,6 @@ package api
 import (
 	"database/sql"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -15,22 +14,59 @@ import (
 	custommw "github.com/bilbothegreedy/server-name-generator/internal/api/middleware"
 	"github.com/bilbothegreedy/server-name-generator/internal/auth"
 	"github.com/bilbothegreedy/server-name-generator/internal/config"
+	appdb "github.com/bilbothegreedy/server-name-generator/internal/db"
+	"github.com/bilbothegreedy/server-name-generator/internal/metrics"
 	"github.com/bilbothegreedy/server-name-generator/internal/models"
 	"github.com/bilbothegreedy/server-name-generator/internal/services"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
 	"github.com/bilbothegreedy/server-name-generator/internal/utils"
 )
 
+// NewNameService builds the name generator service shared by the router and
+// the background workers
+func NewNameService(cfg *config.Config, db *sql.DB, logger *utils.Logger) *services.NameGeneratorService {
+	// Initialize the transaction runner used for serializable retries.
+	txRunner := appdb.NewTxRunner(db, appdb.RetryPolicy{
+		MaxRetries: cfg.Database.TxMaxRetries,
+		BaseDelay:  cfg.Database.TxRetryBaseDelay,
+		MaxDelay:   cfg.Database.TxRetryMaxDelay,
+		Budget:     cfg.Database.TxRetryBudget,
+	}, logger)
+
+	// The outbox is only written when a relay will publish it.
+	var outboxModel *models.OutboxModel
+	if len(cfg.Outbox.Sinks) > 0 {
+		outboxModel = models.NewOutboxModel(db)
+	}
+
+	return services.NewNameGeneratorService(
+		db,
+		txRunner,
+		models.NewSequenceModel(db),
+		models.NewReservationModel(db),
+		models.NewNamingSchemeModel(db),
+		models.NewCatalogModel(db),
+		models.NewEventModel(db),
+		models.NewWebhookModel(db),
+		outboxModel,
+		services.ReservationLifetimes{
+			TTL:        cfg.Reservations.DefaultTTL,
+			Quarantine: cfg.Reservations.Quarantine,
+		},
+		logger,
+	)
+}
+
 // SetupRouter configures and returns the API router.
 // The startTime parameter should be the application start time.
-func SetupRouter(cfg *config.Config, db *sql.DB, logger *utils.Logger, startTime time.Time) http.Handler {
+func SetupRouter(cfg *config.Config, db *sql.DB, nameService *services.NameGeneratorService, eventBroker *services.EventBroker, logger *utils.Logger, startTime time.Time) http.Handler {
 	// Initialize models.
-	sequenceModel := models.NewSequenceModel(db)
-	reservationModel := models.NewReservationModel(db)
 	userModel := models.NewUserModel(db)
 	apiKeyModel := models.NewAPIKeyModel(db)
-
-	// Initialize services.
-	nameService := services.NewNameGeneratorService(db, sequenceModel, reservationModel, logger)
+	schemeModel := models.NewNamingSchemeModel(db)
+	catalogModel := models.NewCatalogModel(db)
+	idempotencyModel := models.NewIdempotencyModel(db)
+	webhookModel := models.NewWebhookModel(db)
 
 	// Initialize JWT manager.
 	jwtManager := auth.NewJWTManager(cfg.Auth.JWTSecret, cfg.Auth.TokenDuration)
@@ -42,25 +78,34 @@ func SetupRouter(cfg *config.Config, db *sql.DB, logger *utils.Logger, startTime
 	authHandler := handlers.NewAuthHandler(userModel, jwtManager, logger)
 	userManagementHandler := handlers.NewUserManagementHandler(userModel, logger)
 	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyModel, userModel, logger)
+	schemeHandler := handlers.NewSchemeHandler(nameService, schemeModel, logger)
+	catalogHandler := handlers.NewCatalogHandler(catalogModel, logger)
+	sequenceHandler := handlers.NewSequenceHandler(nameService, logger)
+	nameHandler := handlers.NewNameHandler(nameService, logger)
+	webhookHandler := handlers.NewWebhookHandler(webhookModel, logger)
+	eventHandler := handlers.NewEventHandler(eventBroker, logger)
 
 	// Create router.
 	r := chi.NewRouter()
 
 	// Global middleware.
 	r.Use(custommw.RequestIDMiddleware()) // Custom request ID middleware.
+	r.Use(tracing.Middleware())           // Server span linked to the request ID.
 	r.Use(middleware.RealIP)
 	r.Use(middleware.RequestID) // Fallback request ID.
 	r.Use(middleware.Recoverer) // Fallback recovery.
 	r.Use(custommw.ErrorHandler(logger))
 	r.Use(custommw.RequestLogger(logger))
-	r.Use(middleware.Timeout(30 * time.Second))
+	// The event stream is long-lived and exports stream large result sets,
+	// so both are exempt from the request timeout.
+	r.Use(custommw.SkipForPaths(middleware.Timeout(30*time.Second), "/api/events/stream", "/api/reservations/export"))
 
 	// CORS configuration.
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-API-Key"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-API-Key", "Last-Event-ID", "traceparent", "tracestate"},
+		ExposedHeaders:   []string{"Link", "X-Request-ID", "X-Trace-ID"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -84,30 +129,61 @@ func SetupRouter(cfg *config.Config, db *sql.DB, logger *utils.Logger, startTime
 			// Combined authentication middleware (JWT or API Key).
 			r.Use(custommw.CombinedAuth(jwtManager, apiKeyModel, logger))
 
+			// Mutating endpoints replay their response for a repeated Idempotency-Key.
+			idempotent := custommw.Idempotency(idempotencyModel, cfg.Reservations.IdempotencyTTL, logger)
+
 			// Regular user endpoints.
-			r.With(custommw.ValidateReservationRequest(logger)).
+			r.With(idempotent, custommw.ValidateReservationRequest(logger)).
 				Post("/reserve", reservationHandler.Reserve)
+			r.With(idempotent).Post("/reserve/batch", reservationHandler.ReserveBatch)
+			r.With(idempotent).Post("/reserve/explicit", reservationHandler.ReserveExplicit)
+			r.With(custommw.ValidateReservationRequest(logger)).
+				Post("/reserve/preview", reservationHandler.Preview)
 
-			r.With(custommw.ValidateCommitRequest(logger)).
+			r.With(idempotent, custommw.ValidateCommitRequest(logger)).
 				Post("/commit", commitHandler.Commit)
 
+			// Reservation tags can be edited after reserving by the owner or
+			// an admin; API keys need the write scope.
+			r.With(custommw.RequireAPIKeyScope("write")).
+				Patch("/reservations/{id}/tags", reservationHandler.UpdateTags)
+
+			// Single reservations can be read by any authenticated user;
+			// API keys need the read scope.
+			r.Group(func(r chi.Router) {
+				r.Use(custommw.RequireAPIKeyScope("read"))
+				r.Get("/reservations/{id}", reservationHandler.Get)
+				r.Get("/reservations/{id}/history", reservationHandler.History)
+				r.Get("/names/{name}", nameHandler.Get)
+
+				// Decoding includes the matching reservation.
+				r.Get("/names/{name}/decode", nameHandler.Decode)
+
+				// Live reservation events as Server-Sent Events.
+				r.Get("/events/stream", eventHandler.Stream)
+			})
+
+			// Naming schemes can be browsed by any authenticated user.
+			r.Get("/schemes", schemeHandler.GetAll)
+			r.Get("/schemes/{id}", schemeHandler.Get)
+
+			// Segment catalogs can be browsed by any authenticated user.
+			r.Get("/catalogs/{segment}", catalogHandler.List)
 			// Admin-only endpoints.
 			r.Group(func(r chi.Router) {
 				r.Use(custommw.RequireRole(models.RoleAdmin))
 
-				// Get all reservations.
-				r.Get("/reservations", func(w http.ResponseWriter, r *http.Request) {
-					reservations, err := nameService.GetAllReservations(r.Context())
-					if err != nil {
-						logger.Error("Failed to get reservations", "error", err)
-						utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get reservations")
-						return
-					}
-					utils.RespondWithJSON(w, http.StatusOK, reservations)
-				})
+				// Get all reservations, optionally filtered by owner.
+				r.Get("/reservations", reservationHandler.List)
+
+				// Stream every matching reservation as CSV, JSON Lines or YAML.
+				r.Get("/reservations/export", reservationHandler.Export)
+
+				// Import existing names as committed reservations.
+				r.Post("/reservations/import", reservationHandler.Import)
 
 				// Release endpoint – requires admin role.
-				r.With(custommw.ValidateReleaseRequest(logger)).
+				r.With(idempotent, custommw.ValidateReleaseRequest(logger)).
 					Post("/release", releaseHandler.Release)
 
 				// Delete a reservation (admin use only).
@@ -123,11 +199,7 @@ func SetupRouter(cfg *config.Config, db *sql.DB, logger *utils.Logger, startTime
 					err := nameService.DeleteReservation(r.Context(), id)
 					if err != nil {
 						logger.Error("Failed to delete reservation", "error", err, "id", id)
-						if strings.Contains(err.Error(), "not found") {
-							utils.RespondWithError(w, http.StatusNotFound, "Reservation not found")
-							return
-						}
-						utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete reservation: "+err.Error())
+						utils.RespondWithAppError(w, r.Context(), err)
 						return
 					}
 
@@ -137,6 +209,38 @@ func SetupRouter(cfg *config.Config, db *sql.DB, logger *utils.Logger, startTime
 					})
 				})
 
+				// Reservation lifecycle.
+				r.Post("/reservations/{id}/decommission", reservationHandler.Decommission)
+				r.Post("/reservations/{id}/recommission", reservationHandler.Recommission)
+				r.Post("/reservations/{id}/retire", reservationHandler.Retire)
+
+				// Naming scheme management.
+				r.Post("/schemes", schemeHandler.Create)
+				r.Delete("/schemes/{id}", schemeHandler.Deactivate)
+
+				// Segment catalog management.
+				r.Post("/catalogs/{segment}", catalogHandler.Create)
+				r.Put("/catalogs/{segment}/{code}", catalogHandler.Update)
+				r.Delete("/catalogs/{segment}/{code}", catalogHandler.Delete)
+
+				// Webhook subscriptions and their dead letters.
+				r.Route("/webhooks", func(r chi.Router) {
+					r.Get("/", webhookHandler.List)
+					r.Post("/", webhookHandler.Create)
+					r.Get("/dead-letters", webhookHandler.DeadLetters)
+					r.Post("/dead-letters/{deliveryId}/retry", webhookHandler.Redeliver)
+					r.Get("/{id}", webhookHandler.Get)
+					r.Put("/{id}", webhookHandler.Update)
+					r.Delete("/{id}", webhookHandler.Delete)
+					r.Post("/{id}/ping", webhookHandler.Ping)
+				})
+
+				// Sequence counter administration.
+				r.Get("/sequences", sequenceHandler.GetAll)
+				r.Put("/sequences", sequenceHandler.Seed)
+				r.Post("/sequences/reset", sequenceHandler.Reset)
+				r.Put("/sequences/settings", sequenceHandler.UpdateSettings)
+
 				// Get stats for dashboard.
 				r.Get("/stats", func(w http.ResponseWriter, r *http.Request) {
 					stats, err := nameService.GetStats(r.Context())
@@ -191,5 +295,8 @@ func SetupRouter(cfg *config.Config, db *sql.DB, logger *utils.Logger, startTime
 
 	// Register the comprehensive health check endpoint.
 	r.Get("/api/health", health.GetHealthCheck(cfg, db, logger, startTime))
+
+	// Prometheus metrics, guarded by METRICS_TOKEN when it is set.
+	r.Handle("/metrics", metrics.Handler(cfg.Metrics.Token))
 	return r
 }
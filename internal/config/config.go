@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -19,6 +20,61 @@ type DatabaseConfig struct {
 	SSLMode  string
 	MaxConns int
 	Timeout  time.Duration
+
+	// Retry policy for serialization failures in transactions
+	TxMaxRetries     int
+	TxRetryBaseDelay time.Duration
+	TxRetryMaxDelay  time.Duration
+	TxRetryBudget    time.Duration
+}
+
+// ReservationConfig holds reservation lifetime configuration
+type ReservationConfig struct {
+	DefaultTTL     time.Duration // Lifetime of uncommitted reservations, 0 to never expire
+	ReaperInterval time.Duration // How often stale reservations are expired
+	IdempotencyTTL time.Duration // How long Idempotency-Key responses are replayed
+	Quarantine     time.Duration // How long decommissioned names are kept from reuse
+}
+
+// WebhookConfig holds webhook delivery configuration
+type WebhookConfig struct {
+	DispatchInterval time.Duration // How often the delivery queue is polled
+	Timeout          time.Duration // Timeout for a single delivery request
+	MaxAttempts      int           // Attempts before a delivery becomes a dead letter
+	RetryBaseDelay   time.Duration // Delay after the first failure, doubled on each failure
+	RetryMaxDelay    time.Duration // Upper bound for a single retry delay
+	Retention        time.Duration // How long delivered deliveries are kept
+}
+
+// OutboxConfig holds transactional outbox relay configuration
+type OutboxConfig struct {
+	Sinks          []string      // Sinks to publish to: stdout, file, webhook; none disables the outbox
+	RelayInterval  time.Duration // How often the outbox is polled
+	Retention      time.Duration // How long published messages are kept
+	MaxAttempts    int           // Attempts before a message is dead lettered
+	FilePath       string        // Output file of the file sink
+	WebhookURL     string        // Target of the webhook sink
+	WebhookSecret  string        // Optional signing secret of the webhook sink
+	WebhookTimeout time.Duration // Timeout for a single webhook sink request
+}
+
+// EventStreamConfig holds live event stream configuration
+type EventStreamConfig struct {
+	PollInterval time.Duration // How often new reservation events are polled
+}
+
+// MetricsConfig holds Prometheus metrics configuration
+type MetricsConfig struct {
+	Token string // Bearer token required to scrape /metrics, empty for none
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Exporter     string  // Span exporter: none, otlp, stdout or file
+	OTLPEndpoint string  // OTLP/HTTP collector URL, defaults to the OTEL_EXPORTER_OTLP_* variables
+	FilePath     string  // Output file of the file exporter
+	SampleRatio  float64 // Fraction of new traces that are sampled
+	ServiceName  string  // Service name reported with every span
 }
 
 // AuthConfig holds authentication configuration
@@ -29,10 +85,16 @@ type AuthConfig struct {
 
 // Config holds all configuration for the application
 type Config struct {
-	Port     int
-	LogLevel string
-	Database DatabaseConfig
-	Auth     AuthConfig
+	Port         int
+	LogLevel     string
+	Database     DatabaseConfig
+	Auth         AuthConfig
+	Reservations ReservationConfig
+	Webhooks     WebhookConfig
+	Outbox       OutboxConfig
+	EventStream  EventStreamConfig
+	Metrics      MetricsConfig
+	Tracing      TracingConfig
 }
 
 // Load reads configuration from environment variables
@@ -64,6 +126,134 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid DB_TIMEOUT: %w", err)
 	}
 
+	txMaxRetries, err := strconv.Atoi(getEnv("DB_TX_MAX_RETRIES", "5"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_TX_MAX_RETRIES: %w", err)
+	}
+
+	txRetryBaseDelay, err := time.ParseDuration(getEnv("DB_TX_RETRY_BASE_DELAY", "10ms"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_TX_RETRY_BASE_DELAY: %w", err)
+	}
+
+	txRetryMaxDelay, err := time.ParseDuration(getEnv("DB_TX_RETRY_MAX_DELAY", "500ms"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_TX_RETRY_MAX_DELAY: %w", err)
+	}
+
+	txRetryBudget, err := time.ParseDuration(getEnv("DB_TX_RETRY_BUDGET", "2s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_TX_RETRY_BUDGET: %w", err)
+	}
+
+	// Reservation configuration
+	reservationTTL, err := time.ParseDuration(getEnv("RESERVATION_TTL", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESERVATION_TTL: %w", err)
+	}
+
+	reaperInterval, err := time.ParseDuration(getEnv("RESERVATION_REAPER_INTERVAL", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESERVATION_REAPER_INTERVAL: %w", err)
+	}
+	if reaperInterval <= 0 {
+		return nil, fmt.Errorf("invalid RESERVATION_REAPER_INTERVAL: must be positive")
+	}
+
+	idempotencyTTL, err := time.ParseDuration(getEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_TTL: %w", err)
+	}
+
+	quarantine, err := time.ParseDuration(getEnv("NAME_QUARANTINE_PERIOD", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NAME_QUARANTINE_PERIOD: %w", err)
+	}
+
+	// Webhook configuration
+	webhookInterval, err := time.ParseDuration(getEnv("WEBHOOK_DISPATCH_INTERVAL", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_DISPATCH_INTERVAL: %w", err)
+	}
+	if webhookInterval <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_DISPATCH_INTERVAL: must be positive")
+	}
+
+	webhookTimeout, err := time.ParseDuration(getEnv("WEBHOOK_TIMEOUT", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_TIMEOUT: %w", err)
+	}
+	if webhookTimeout <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_TIMEOUT: must be positive")
+	}
+
+	webhookMaxAttempts, err := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	if err != nil || webhookMaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: must be a positive integer")
+	}
+
+	webhookBaseDelay, err := time.ParseDuration(getEnv("WEBHOOK_RETRY_BASE_DELAY", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_RETRY_BASE_DELAY: %w", err)
+	}
+	if webhookBaseDelay <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_RETRY_BASE_DELAY: must be positive")
+	}
+
+	webhookMaxDelay, err := time.ParseDuration(getEnv("WEBHOOK_RETRY_MAX_DELAY", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_RETRY_MAX_DELAY: %w", err)
+	}
+	if webhookMaxDelay <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_RETRY_MAX_DELAY: must be positive")
+	}
+
+	webhookRetention, err := time.ParseDuration(getEnv("WEBHOOK_RETENTION", "168h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_RETENTION: %w", err)
+	}
+
+	// Outbox configuration
+	var outboxSinks []string
+	for _, sink := range strings.Split(getEnv("OUTBOX_SINKS", ""), ",") {
+		if sink = strings.TrimSpace(sink); sink != "" {
+			outboxSinks = append(outboxSinks, sink)
+		}
+	}
+
+	outboxInterval, err := time.ParseDuration(getEnv("OUTBOX_RELAY_INTERVAL", "1s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OUTBOX_RELAY_INTERVAL: %w", err)
+	}
+	if outboxInterval <= 0 {
+		return nil, fmt.Errorf("invalid OUTBOX_RELAY_INTERVAL: must be positive")
+	}
+
+	outboxRetention, err := time.ParseDuration(getEnv("OUTBOX_RETENTION", "168h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OUTBOX_RETENTION: %w", err)
+	}
+
+	outboxMaxAttempts, err := strconv.Atoi(getEnv("OUTBOX_MAX_ATTEMPTS", "10"))
+	if err != nil || outboxMaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid OUTBOX_MAX_ATTEMPTS: must be a positive integer")
+	}
+
+	// Event stream configuration
+	eventPollInterval, err := time.ParseDuration(getEnv("EVENT_STREAM_POLL_INTERVAL", "500ms"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid EVENT_STREAM_POLL_INTERVAL: %w", err)
+	}
+	if eventPollInterval <= 0 {
+		return nil, fmt.Errorf("invalid EVENT_STREAM_POLL_INTERVAL: must be positive")
+	}
+
+	// Tracing configuration
+	sampleRatio, err := strconv.ParseFloat(getEnv("TRACING_SAMPLE_RATIO", "1"), 64)
+	if err != nil || sampleRatio < 0 || sampleRatio > 1 {
+		return nil, fmt.Errorf("invalid TRACING_SAMPLE_RATIO: must be between 0 and 1")
+	}
+
 	// Authentication configuration
 	jwtSecret := getEnv("JWT_SECRET", "")
 	if jwtSecret == "" {
@@ -90,11 +280,53 @@ func Load() (*Config, error) {
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 			MaxConns: dbMaxConns,
 			Timeout:  dbTimeout,
+
+			TxMaxRetries:     txMaxRetries,
+			TxRetryBaseDelay: txRetryBaseDelay,
+			TxRetryMaxDelay:  txRetryMaxDelay,
+			TxRetryBudget:    txRetryBudget,
 		},
 		Auth: AuthConfig{
 			JWTSecret:     jwtSecret,
 			TokenDuration: tokenDuration,
 		},
+		Reservations: ReservationConfig{
+			DefaultTTL:     reservationTTL,
+			ReaperInterval: reaperInterval,
+			IdempotencyTTL: idempotencyTTL,
+			Quarantine:     quarantine,
+		},
+		Webhooks: WebhookConfig{
+			DispatchInterval: webhookInterval,
+			Timeout:          webhookTimeout,
+			MaxAttempts:      webhookMaxAttempts,
+			RetryBaseDelay:   webhookBaseDelay,
+			RetryMaxDelay:    webhookMaxDelay,
+			Retention:        webhookRetention,
+		},
+		Outbox: OutboxConfig{
+			Sinks:          outboxSinks,
+			RelayInterval:  outboxInterval,
+			Retention:      outboxRetention,
+			MaxAttempts:    outboxMaxAttempts,
+			FilePath:       getEnv("OUTBOX_FILE_PATH", ""),
+			WebhookURL:     getEnv("OUTBOX_WEBHOOK_URL", ""),
+			WebhookSecret:  getEnv("OUTBOX_WEBHOOK_SECRET", ""),
+			WebhookTimeout: webhookTimeout,
+		},
+		EventStream: EventStreamConfig{
+			PollInterval: eventPollInterval,
+		},
+		Metrics: MetricsConfig{
+			Token: getEnv("METRICS_TOKEN", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:     getEnv("TRACING_EXPORTER", "none"),
+			OTLPEndpoint: getEnv("TRACING_OTLP_ENDPOINT", ""),
+			FilePath:     getEnv("TRACING_FILE_PATH", ""),
+			SampleRatio:  sampleRatio,
+			ServiceName:  getEnv("TRACING_SERVICE_NAME", "server-name-generator"),
+		},
 	}, nil
 }
 
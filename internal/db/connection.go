@@ -10,11 +10,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 
 	"github.com/bilbothegreedy/server-name-generator/internal/config"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
 	"github.com/bilbothegreedy/server-name-generator/internal/utils"
 )
 
@@ -35,8 +37,8 @@ func Connect(cfg config.DatabaseConfig, logger *utils.Logger) (*sql.DB, error) {
 		RawQuery: urlQuery.Encode(),
 	}
 
-	// Open connection to database
-	db, err := sql.Open("postgres", dbURL.String())
+	// Open connection to database, tracing each statement
+	db, err := otelsql.Open("postgres", dbURL.String(), tracing.SQLOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
@@ -0,0 +1,94 @@
+// Package dbtest provides migrated PostgreSQL databases for tests.
+package dbtest
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// EnvURL names the environment variable holding the connection URL of the
+// database tests may use. Tests that need a database are skipped without it.
+const EnvURL = "TEST_DATABASE_URL"
+
+// Open creates an empty schema in the test database, runs every migration
+// into it and returns a connection pool bound to that schema. The schema is
+// dropped when the test finishes, so tests never see each other's rows.
+func Open(tb testing.TB) *sql.DB {
+	tb.Helper()
+
+	baseURL := os.Getenv(EnvURL)
+	if baseURL == "" {
+		tb.Skipf("%s is not set", EnvURL)
+	}
+
+	admin, err := sql.Open("postgres", baseURL)
+	if err != nil {
+		tb.Fatalf("failed to open test database: %v", err)
+	}
+	tb.Cleanup(func() { admin.Close() })
+
+	schema := "test_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	if _, err := admin.Exec(fmt.Sprintf("CREATE SCHEMA %s", schema)); err != nil {
+		tb.Fatalf("failed to create schema %s: %v", schema, err)
+	}
+	tb.Cleanup(func() {
+		if _, err := admin.Exec(fmt.Sprintf("DROP SCHEMA %s CASCADE", schema)); err != nil {
+			tb.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	schemaURL, err := withSearchPath(baseURL, schema)
+	if err != nil {
+		tb.Fatalf("invalid %s: %v", EnvURL, err)
+	}
+
+	m, err := migrate.New("file://"+migrationsDir(), schemaURL)
+	if err != nil {
+		tb.Fatalf("failed to create migrator: %v", err)
+	}
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		m.Close()
+		tb.Fatalf("failed to run migrations: %v", err)
+	}
+	m.Close()
+
+	db, err := sql.Open("postgres", schemaURL)
+	if err != nil {
+		tb.Fatalf("failed to open test schema: %v", err)
+	}
+	// Registered last so the pool is closed before the schema is dropped
+	tb.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// withSearchPath points every connection opened from rawURL at schema
+func withSearchPath(rawURL, schema string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// migrationsDir returns the repository's migrations folder, found relative
+// to this source file so tests work from any package directory
+func migrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.ToSlash(filepath.Join(filepath.Dir(file), "..", "..", "..", "migrations"))
+}
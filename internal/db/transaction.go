@@ -3,9 +3,23 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/metrics"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
 )
 
+// ErrRollback can be returned by a transaction function to discard its
+// changes without reporting a failure
+var ErrRollback = errors.New("transaction rolled back")
+
 // ExecuteInTransaction runs the given function in a database transaction
 func ExecuteInTransaction(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
 	// Start transaction
@@ -26,6 +40,9 @@ func ExecuteInTransaction(ctx context.Context, db *sql.DB, fn func(*sql.Tx) erro
 
 	// Execute the function in the transaction
 	err = fn(tx)
+	if errors.Is(err, ErrRollback) {
+		return nil // Rollback happens in the deferred function
+	}
 	if err != nil {
 		return err // Rollback happens in the deferred function
 	}
@@ -37,3 +54,129 @@ func ExecuteInTransaction(ctx context.Context, db *sql.DB, fn func(*sql.Tx) erro
 
 	return nil
 }
+
+// PostgreSQL error codes that indicate a transaction can safely be retried
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+	sqlStateUniqueViolation      = "23505"
+)
+
+// IsRetryable reports whether err is a serialization failure or deadlock
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == sqlStateSerializationFailure || pqErr.Code == sqlStateDeadlockDetected
+}
+
+// IsUniqueViolation reports whether err is a unique constraint violation
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == sqlStateUniqueViolation
+}
+
+// RetryPolicy controls how retryable transaction failures are retried
+type RetryPolicy struct {
+	MaxRetries int           // Retries allowed after the first attempt
+	BaseDelay  time.Duration // Backoff ceiling for the first retry, doubled on each retry
+	MaxDelay   time.Duration // Upper bound for a single backoff
+	Budget     time.Duration // Total time that may be spent backing off, 0 for no limit
+}
+
+// backoff returns a full-jitter delay for the given retry (starting at 1)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.BaseDelay << (retry - 1)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling)) + 1)
+}
+
+// TxRunner runs functions in serializable transactions and retries them on
+// serialization failures and deadlocks
+type TxRunner struct {
+	db     *sql.DB
+	policy RetryPolicy
+	logger *utils.Logger
+}
+
+// NewTxRunner creates a new transaction runner
+func NewTxRunner(db *sql.DB, policy RetryPolicy, logger *utils.Logger) *TxRunner {
+	return &TxRunner{
+		db:     db,
+		policy: policy,
+		logger: logger,
+	}
+}
+
+// Run executes fn with ExecuteInTransaction, retrying with jittered
+// exponential backoff while the failure is retryable and the retry budget
+// allows. fn must be safe to run more than once. The name identifies the
+// operation in logs.
+func (r *TxRunner) Run(ctx context.Context, name string, fn func(*sql.Tx) error) error {
+	var waited time.Duration
+	retries := 0
+
+	for {
+		err := ExecuteInTransaction(ctx, r.db, fn)
+		if err == nil {
+			if retries > 0 {
+				r.logger.WithRequestID(ctx).Info("Transaction succeeded after retries",
+					"operation", name,
+					"retries", retries,
+				)
+			}
+			return nil
+		}
+
+		if !IsRetryable(err) {
+			return err
+		}
+
+		if retries >= r.policy.MaxRetries {
+			r.logger.WithRequestID(ctx).Warn("Transaction retries exhausted",
+				"operation", name,
+				"retries", retries,
+				"error", err,
+			)
+			return err
+		}
+
+		delay := r.policy.backoff(retries + 1)
+		if r.policy.Budget > 0 && waited+delay > r.policy.Budget {
+			r.logger.WithRequestID(ctx).Warn("Transaction retry budget exhausted",
+				"operation", name,
+				"retries", retries,
+				"waited_ms", waited.Milliseconds(),
+				"error", err,
+			)
+			return err
+		}
+
+		retries++
+		waited += delay
+		metrics.RecordTxRetry(name)
+		trace.SpanFromContext(ctx).AddEvent("transaction retry", trace.WithAttributes(
+			attribute.String("operation", name),
+			attribute.Int("retry", retries),
+			attribute.String("error", err.Error()),
+		))
+		r.logger.WithRequestID(ctx).Debug("Retrying transaction",
+			"operation", name,
+			"retry", retries,
+			"delay_ms", delay.Milliseconds(),
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
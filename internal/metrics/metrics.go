@@ -0,0 +1,145 @@
+// Package metrics exposes application metrics in the Prometheus text
+// exposition format
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	apperrors "github.com/bilbothegreedy/server-name-generator/internal/errors"
+)
+
+const namespace = "server_names"
+
+// Registry holds every metric served by Handler
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	operations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reservation_operations_total",
+		Help:      "Reserve, commit and release operations by outcome.",
+	}, []string{"operation", "outcome"})
+
+	txRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transaction_retries_total",
+		Help:      "Transactions retried after a serialization failure or deadlock.",
+	}, []string{"operation"})
+
+	authFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_key_auth_failures_total",
+		Help:      "Rejected API key authentications by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		operations,
+		txRetries,
+		authFailures,
+	)
+}
+
+// Reservation operations
+const (
+	OperationReserve = "reserve"
+	OperationCommit  = "commit"
+	OperationRelease = "release"
+)
+
+// API key authentication failure reasons
+const (
+	AuthFailureInvalidKey   = "invalid_key"
+	AuthFailureLookup       = "lookup_error"
+	AuthFailureMissingScope = "missing_scope"
+)
+
+// ObserveHTTPRequest records a served request. Route is the matched route
+// pattern rather than the raw path so that IDs do not create new series.
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	labels := prometheus.Labels{
+		"method": method,
+		"route":  route,
+		"status": strconv.Itoa(status),
+	}
+	httpRequests.With(labels).Inc()
+	httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+// RecordOperation records the outcome of a reservation operation: success,
+// or the error type of a failure
+func RecordOperation(operation string, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+		var appErr *apperrors.AppError
+		if errors.As(err, &appErr) {
+			outcome = string(appErr.Type)
+		}
+	}
+	operations.WithLabelValues(operation, outcome).Inc()
+}
+
+// RecordTxRetry records a retried transaction
+func RecordTxRetry(operation string) {
+	txRetries.WithLabelValues(operation).Inc()
+}
+
+// RecordAuthFailure records a rejected API key
+func RecordAuthFailure(reason string) {
+	authFailures.WithLabelValues(reason).Inc()
+}
+
+// RegisterDBStats exposes the connection pool statistics of db
+func RegisterDBStats(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the registry. When token is set, requests must present it
+// as a bearer token.
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return handler
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CatalogValue is an allowed code for one segment of a server name
+type CatalogValue struct {
+	Segment     string    `json:"segment"`
+	Code        string    `json:"code"`
+	Description string    `json:"description"`
+	Deprecated  bool      `json:"deprecated"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// CatalogModel handles database operations for segment catalogs
+type CatalogModel struct {
+	DB *sql.DB
+}
+
+// NewCatalogModel creates a new catalog model
+func NewCatalogModel(db *sql.DB) *CatalogModel {
+	return &CatalogModel{DB: db}
+}
+
+// IsCatalogSegment reports whether segment names a field that can have a catalog
+func IsCatalogSegment(segment string) bool {
+	switch segment {
+	case SegmentUnitCode, SegmentType, SegmentProvider, SegmentRegion, SegmentEnvironment, SegmentFunction:
+		return true
+	default:
+		return false
+	}
+}
+
+// Create inserts a new catalog value
+func (m *CatalogModel) Create(ctx context.Context, v *CatalogValue) error {
+	query := `
+		INSERT INTO segment_catalog (
+			segment, code, description, deprecated, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		)
+	`
+
+	_, err := m.DB.ExecContext(
+		ctx,
+		query,
+		v.Segment,
+		v.Code,
+		v.Description,
+		v.Deprecated,
+		v.CreatedAt,
+		v.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create catalog value: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves a single catalog value
+func (m *CatalogModel) Get(ctx context.Context, segment, code string) (*CatalogValue, error) {
+	query := `
+		SELECT segment, code, description, deprecated, created_at, updated_at
+		FROM segment_catalog
+		WHERE segment = $1 AND code = $2
+	`
+
+	v := &CatalogValue{}
+	err := m.DB.QueryRowContext(ctx, query, segment, code).Scan(
+		&v.Segment,
+		&v.Code,
+		&v.Description,
+		&v.Deprecated,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get catalog value: %w", err)
+	}
+
+	return v, nil
+}
+
+// GetBySegment retrieves all catalog values for a segment ordered by code
+func (m *CatalogModel) GetBySegment(ctx context.Context, segment string) ([]*CatalogValue, error) {
+	query := `
+		SELECT segment, code, description, deprecated, created_at, updated_at
+		FROM segment_catalog
+		WHERE segment = $1
+		ORDER BY code
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, segment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog values: %w", err)
+	}
+	defer rows.Close()
+
+	values := []*CatalogValue{}
+	for rows.Next() {
+		v := &CatalogValue{}
+		err := rows.Scan(
+			&v.Segment,
+			&v.Code,
+			&v.Description,
+			&v.Deprecated,
+			&v.CreatedAt,
+			&v.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan catalog value: %w", err)
+		}
+		values = append(values, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating catalog values: %w", err)
+	}
+
+	return values, nil
+}
+
+// Update changes the description and deprecation flag of a catalog value
+func (m *CatalogModel) Update(ctx context.Context, v *CatalogValue) error {
+	query := `
+		UPDATE segment_catalog
+		SET description = $1, deprecated = $2, updated_at = $3
+		WHERE segment = $4 AND code = $5
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, v.Description, v.Deprecated, v.UpdatedAt, v.Segment, v.Code)
+	if err != nil {
+		return fmt.Errorf("failed to update catalog value: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("catalog value not found")
+	}
+
+	return nil
+}
+
+// Delete removes a catalog value
+func (m *CatalogModel) Delete(ctx context.Context, segment, code string) error {
+	query := `
+		DELETE FROM segment_catalog
+		WHERE segment = $1 AND code = $2
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, segment, code)
+	if err != nil {
+		return fmt.Errorf("failed to delete catalog value: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("catalog value not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Reservation event types
+const (
+	EventCreated        = "created"
+	EventCommitted      = "committed"
+	EventReleased       = "released"
+	EventExpired        = "expired"
+	EventDecommissioned = "decommissioned"
+	EventRecommissioned = "recommissioned"
+	EventRetired        = "retired"
+	EventDeleted        = "deleted"
+	EventReclaimed      = "reclaimed"
+	EventTagsUpdated    = "tags_updated"
+)
+
+// ReservationEvent is an entry in a reservation's append-only history.
+// Before and After hold the reservation as it was before and after the
+// change; Before is empty for creations and After for deletions.
+type ReservationEvent struct {
+	ID            int64        `json:"id"`
+	ReservationID string       `json:"reservationId"`
+	ServerName    string       `json:"serverName"`
+	Type          string       `json:"type"`
+	Actor         string       `json:"actor,omitempty"`
+	APIKeyID      string       `json:"apiKeyId,omitempty"`
+	RequestID     string       `json:"requestId,omitempty"`
+	Before        *Reservation `json:"before,omitempty"`
+	After         *Reservation `json:"after,omitempty"`
+	CreatedAt     time.Time    `json:"createdAt"`
+}
+
+// EventModel handles database operations for reservation events
+type EventModel struct {
+	DB *sql.DB
+}
+
+// NewEventModel creates a new event model
+func NewEventModel(db *sql.DB) *EventModel {
+	return &EventModel{DB: db}
+}
+
+const eventColumns = `
+	id, reservation_id, server_name, event_type, actor, api_key_id, request_id,
+	before_state, after_state, created_at
+`
+
+// scanEvent scans a single event row selected with eventColumns
+func scanEvent(row interface{ Scan(...any) error }) (*ReservationEvent, error) {
+	var actor, apiKeyID, requestID sql.NullString
+	var before, after []byte
+	e := &ReservationEvent{}
+	err := row.Scan(
+		&e.ID,
+		&e.ReservationID,
+		&e.ServerName,
+		&e.Type,
+		&actor,
+		&apiKeyID,
+		&requestID,
+		&before,
+		&after,
+		&e.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Actor = actor.String
+	e.APIKeyID = apiKeyID.String
+	e.RequestID = requestID.String
+	if before != nil {
+		if err := json.Unmarshal(before, &e.Before); err != nil {
+			return nil, fmt.Errorf("failed to decode event state: %w", err)
+		}
+	}
+	if after != nil {
+		if err := json.Unmarshal(after, &e.After); err != nil {
+			return nil, fmt.Errorf("failed to decode event state: %w", err)
+		}
+	}
+	return e, nil
+}
+
+// marshalState encodes a reservation snapshot for a JSONB column
+func marshalState(r *Reservation) ([]byte, error) {
+	if r == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event state: %w", err)
+	}
+	return data, nil
+}
+
+// Record appends an event inside tx and sets its ID
+func (m *EventModel) Record(ctx context.Context, tx *sql.Tx, e *ReservationEvent) error {
+	before, err := marshalState(e.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalState(e.After)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO reservation_events (
+			reservation_id, server_name, event_type, actor, api_key_id, request_id,
+			before_state, after_state, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)
+		RETURNING id
+	`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		e.ReservationID,
+		e.ServerName,
+		e.Type,
+		nullIfEmpty(e.Actor),
+		nullIfEmpty(e.APIKeyID),
+		nullIfEmpty(e.RequestID),
+		before,
+		after,
+		e.CreatedAt,
+	).Scan(&e.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record reservation event: %w", err)
+	}
+
+	return nil
+}
+
+// GetByReservation retrieves the history of a reservation, oldest first
+func (m *EventModel) GetByReservation(ctx context.Context, reservationID string) ([]*ReservationEvent, error) {
+	query := `SELECT ` + eventColumns + ` FROM reservation_events
+		WHERE reservation_id = $1
+		ORDER BY id`
+
+	rows, err := m.DB.QueryContext(ctx, query, reservationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reservation events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*ReservationEvent{}
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation event: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reservation events: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetAfter retrieves up to limit events with an ID above afterID, oldest first
+func (m *EventModel) GetAfter(ctx context.Context, afterID int64, limit int) ([]*ReservationEvent, error) {
+	query := `SELECT ` + eventColumns + ` FROM reservation_events
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`
+
+	rows, err := m.DB.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reservation events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*ReservationEvent
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation event: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reservation events: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetLatestID returns the ID of the newest event, or 0 when there are none
+func (m *EventModel) GetLatestID(ctx context.Context) (int64, error) {
+	var id int64
+	err := m.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM reservation_events`).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest event ID: %w", err)
+	}
+	return id, nil
+}
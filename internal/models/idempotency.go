@@ -0,0 +1,123 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// IdempotencyRecord stores the outcome of a request made with an Idempotency-Key
+type IdempotencyRecord struct {
+	Scope        string
+	Key          string
+	RequestHash  string
+	StatusCode   int // 0 while the original request is still in progress
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// IdempotencyModel handles database operations for idempotency keys
+type IdempotencyModel struct {
+	DB *sql.DB
+}
+
+// NewIdempotencyModel creates a new idempotency model
+func NewIdempotencyModel(db *sql.DB) *IdempotencyModel {
+	return &IdempotencyModel{DB: db}
+}
+
+// Begin claims a key for a new request. It returns true when the key was
+// claimed, either because it was unused or because its previous record had
+// expired, and false when another record for the key is still live.
+func (m *IdempotencyModel) Begin(ctx context.Context, rec *IdempotencyRecord) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (
+			scope, idempotency_key, request_hash, status_code, response_body, created_at, expires_at
+		) VALUES (
+			$1, $2, $3, 0, NULL, $4, $5
+		)
+		ON CONFLICT (scope, idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = 0,
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, rec.Scope, rec.Key, rec.RequestHash, rec.CreatedAt, rec.ExpiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// Get retrieves the record for a key
+func (m *IdempotencyModel) Get(ctx context.Context, scope, key string) (*IdempotencyRecord, error) {
+	query := `
+		SELECT scope, idempotency_key, request_hash, status_code, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND idempotency_key = $2
+	`
+
+	rec := &IdempotencyRecord{}
+	err := m.DB.QueryRowContext(ctx, query, scope, key).Scan(
+		&rec.Scope,
+		&rec.Key,
+		&rec.RequestHash,
+		&rec.StatusCode,
+		&rec.ResponseBody,
+		&rec.CreatedAt,
+		&rec.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return rec, nil
+}
+
+// Complete stores the response for a claimed key
+func (m *IdempotencyModel) Complete(ctx context.Context, scope, key string, statusCode int, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $1, response_body = $2
+		WHERE scope = $3 AND idempotency_key = $4
+	`
+
+	if _, err := m.DB.ExecContext(ctx, query, statusCode, body, scope, key); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a key so the request can be retried
+func (m *IdempotencyModel) Delete(ctx context.Context, scope, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE scope = $1 AND idempotency_key = $2`
+
+	if _, err := m.DB.ExecContext(ctx, query, scope, key); err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes keys whose replay window has passed
+func (m *IdempotencyModel) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,40 @@
+package models
+
+// MaxImportRows is the largest number of rows a single import may contain
+const MaxImportRows = 10000
+
+// Import row outcomes
+const (
+	ImportAccepted = "accepted" // The name was imported, or would be in a dry run
+	ImportRejected = "rejected" // The row is invalid or does not fit a naming scheme
+	ImportConflict = "conflict" // The name is already reserved or repeated in the import
+)
+
+// ImportRow is an existing server name to import as a committed reservation.
+// Without a scheme the active naming schemes are tried, default first.
+type ImportRow struct {
+	ServerName    string `json:"serverName" validate:"required,max=100"`
+	Scheme        string `json:"scheme,omitempty" validate:"omitempty,max=50"`
+	SchemeVersion int    `json:"schemeVersion,omitempty" validate:"omitempty,min=1"`
+	ReservationDetails
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// ImportResult is the outcome of one import row. Row numbers start at 1.
+type ImportResult struct {
+	Row           int    `json:"row"`
+	ServerName    string `json:"serverName"`
+	Status        string `json:"status"`
+	ReservationID string `json:"reservationId,omitempty"`
+	Scheme        string `json:"scheme,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ImportReport summarizes an import
+type ImportReport struct {
+	DryRun    bool            `json:"dryRun"`
+	Accepted  int             `json:"accepted"`
+	Rejected  int             `json:"rejected"`
+	Conflicts int             `json:"conflicts"`
+	Rows      []*ImportResult `json:"rows"`
+}
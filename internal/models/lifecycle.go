@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Lifecycle actions that move a reservation between statuses
+const (
+	ActionCommit       = "commit"
+	ActionRelease      = "release"
+	ActionExpire       = "expire"
+	ActionDecommission = "decommission"
+	ActionRecommission = "recommission"
+	ActionRetire       = "retire"
+	ActionDelete       = "delete"
+)
+
+// StatusDeleted is the target of the delete action; the row is removed
+const StatusDeleted = "deleted"
+
+// lifecycleTransitions maps each action to the statuses it may start from
+// and the status it leads to
+var lifecycleTransitions = map[string]struct {
+	from []string
+	to   string
+}{
+	ActionCommit:       {from: []string{StatusReserved}, to: StatusCommitted},
+	ActionRelease:      {from: []string{StatusCommitted}, to: StatusReserved},
+	ActionExpire:       {from: []string{StatusReserved}, to: StatusExpired},
+	ActionDecommission: {from: []string{StatusCommitted}, to: StatusDecommissioned},
+	ActionRecommission: {from: []string{StatusDecommissioned}, to: StatusCommitted},
+	ActionRetire:       {from: []string{StatusCommitted, StatusDecommissioned}, to: StatusRetired},
+	ActionDelete:       {from: []string{StatusReserved, StatusExpired, StatusDecommissioned}, to: StatusDeleted},
+}
+
+// TransitionError reports a lifecycle action that is not allowed
+type TransitionError struct {
+	Action string
+	Status string
+	Code   string
+	Reason string
+}
+
+func (e *TransitionError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("cannot %s reservation: %s", e.Action, e.Reason)
+	}
+	return fmt.Sprintf("cannot %s a reservation that is %s", e.Action, e.Status)
+}
+
+// Transition checks whether action may be applied to the reservation at now
+// and returns the status it leads to. Besides the status table it enforces
+// reservation expiry and the quarantine of decommissioned names.
+func Transition(r *Reservation, action string, now time.Time) (string, error) {
+	t, ok := lifecycleTransitions[action]
+	if !ok {
+		return "", fmt.Errorf("unknown lifecycle action %q", action)
+	}
+
+	allowed := false
+	for _, from := range t.from {
+		if r.Status == from {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", &TransitionError{Action: action, Status: r.Status, Code: "invalid_transition"}
+	}
+
+	switch {
+	case action == ActionCommit && r.ExpiresAt != nil && !r.ExpiresAt.After(now):
+		return "", &TransitionError{Action: action, Status: r.Status, Code: "reservation_expired",
+			Reason: "reservation has expired"}
+	case action == ActionDelete && r.Status == StatusDecommissioned && r.QuarantineUntil != nil && r.QuarantineUntil.After(now):
+		return "", &TransitionError{Action: action, Status: r.Status, Code: "name_quarantined",
+			Reason: "name is quarantined until " + r.QuarantineUntil.UTC().Format(time.RFC3339)}
+	}
+
+	return t.to, nil
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTransition(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		reservation Reservation
+		action      string
+		want        string
+		wantCode    string
+	}{
+		{name: "commit reserved", reservation: Reservation{Status: StatusReserved}, action: ActionCommit, want: StatusCommitted},
+		{name: "commit before expiry", reservation: Reservation{Status: StatusReserved, ExpiresAt: &future}, action: ActionCommit, want: StatusCommitted},
+		{name: "commit at expiry", reservation: Reservation{Status: StatusReserved, ExpiresAt: &now}, action: ActionCommit, wantCode: "reservation_expired"},
+		{name: "commit after expiry", reservation: Reservation{Status: StatusReserved, ExpiresAt: &past}, action: ActionCommit, wantCode: "reservation_expired"},
+		{name: "commit committed", reservation: Reservation{Status: StatusCommitted}, action: ActionCommit, wantCode: "invalid_transition"},
+		{name: "release committed", reservation: Reservation{Status: StatusCommitted}, action: ActionRelease, want: StatusReserved},
+		{name: "release reserved", reservation: Reservation{Status: StatusReserved}, action: ActionRelease, wantCode: "invalid_transition"},
+		{name: "expire reserved", reservation: Reservation{Status: StatusReserved}, action: ActionExpire, want: StatusExpired},
+		{name: "expire committed", reservation: Reservation{Status: StatusCommitted}, action: ActionExpire, wantCode: "invalid_transition"},
+		{name: "decommission committed", reservation: Reservation{Status: StatusCommitted}, action: ActionDecommission, want: StatusDecommissioned},
+		{name: "decommission reserved", reservation: Reservation{Status: StatusReserved}, action: ActionDecommission, wantCode: "invalid_transition"},
+		{name: "recommission decommissioned", reservation: Reservation{Status: StatusDecommissioned, QuarantineUntil: &future}, action: ActionRecommission, want: StatusCommitted},
+		{name: "recommission retired", reservation: Reservation{Status: StatusRetired}, action: ActionRecommission, wantCode: "invalid_transition"},
+		{name: "retire committed", reservation: Reservation{Status: StatusCommitted}, action: ActionRetire, want: StatusRetired},
+		{name: "retire decommissioned", reservation: Reservation{Status: StatusDecommissioned}, action: ActionRetire, want: StatusRetired},
+		{name: "retire retired", reservation: Reservation{Status: StatusRetired}, action: ActionRetire, wantCode: "invalid_transition"},
+		{name: "delete reserved", reservation: Reservation{Status: StatusReserved}, action: ActionDelete, want: StatusDeleted},
+		{name: "delete expired", reservation: Reservation{Status: StatusExpired}, action: ActionDelete, want: StatusDeleted},
+		{name: "delete committed", reservation: Reservation{Status: StatusCommitted}, action: ActionDelete, wantCode: "invalid_transition"},
+		{name: "delete retired", reservation: Reservation{Status: StatusRetired}, action: ActionDelete, wantCode: "invalid_transition"},
+		{name: "delete in quarantine", reservation: Reservation{Status: StatusDecommissioned, QuarantineUntil: &future}, action: ActionDelete, wantCode: "name_quarantined"},
+		{name: "delete after quarantine", reservation: Reservation{Status: StatusDecommissioned, QuarantineUntil: &past}, action: ActionDelete, want: StatusDeleted},
+		{name: "delete at quarantine end", reservation: Reservation{Status: StatusDecommissioned, QuarantineUntil: &now}, action: ActionDelete, want: StatusDeleted},
+		{name: "delete without quarantine", reservation: Reservation{Status: StatusDecommissioned}, action: ActionDelete, want: StatusDeleted},
+	}
+
+	for _, tt := range tests {
+		got, err := Transition(&tt.reservation, tt.action, now)
+		if tt.wantCode == "" {
+			if err != nil || got != tt.want {
+				t.Errorf("%s: Transition() = %q, %v; want %q", tt.name, got, err, tt.want)
+			}
+			continue
+		}
+
+		var transitionErr *TransitionError
+		if !errors.As(err, &transitionErr) {
+			t.Errorf("%s: Transition() error = %v, want a TransitionError", tt.name, err)
+			continue
+		}
+		if transitionErr.Code != tt.wantCode {
+			t.Errorf("%s: Transition() code = %q, want %q", tt.name, transitionErr.Code, tt.wantCode)
+		}
+		if got != "" {
+			t.Errorf("%s: Transition() status = %q on error", tt.name, got)
+		}
+	}
+}
+
+func TestTransitionUnknownAction(t *testing.T) {
+	_, err := Transition(&Reservation{Status: StatusReserved}, "archive", time.Now())
+	if err == nil {
+		t.Fatal("Transition() with an unknown action succeeded")
+	}
+
+	var transitionErr *TransitionError
+	if errors.As(err, &transitionErr) {
+		t.Errorf("Transition() error = %v, want a plain error", err)
+	}
+}
@@ -0,0 +1,362 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Segment fields a naming scheme can place in a server name
+const (
+	SegmentUnitCode    = "unitCode"
+	SegmentType        = "type"
+	SegmentProvider    = "provider"
+	SegmentRegion      = "region"
+	SegmentEnvironment = "environment"
+	SegmentFunction    = "function"
+)
+
+// Character sets a segment value may be restricted to
+const (
+	CharsetAlpha        = "alpha"
+	CharsetNumeric      = "numeric"
+	CharsetAlphanumeric = "alphanumeric"
+)
+
+// Letter cases a naming scheme can render names in
+const (
+	CaseUpper = "upper"
+	CaseLower = "lower"
+)
+
+// DefaultSchemeName is the name of the built-in compact naming scheme
+const DefaultSchemeName = "compact"
+
+// SchemeSegment describes one segment of a server name
+type SchemeSegment struct {
+	Field   string `json:"field" validate:"required,oneof=unitCode type provider region environment function"`
+	Length  int    `json:"length" validate:"required,min=1,max=10"`
+	Charset string `json:"charset,omitempty" validate:"omitempty,oneof=alpha numeric alphanumeric"`
+	Default string `json:"default,omitempty"`
+}
+
+// NamingScheme defines how a server name is assembled from its segments
+type NamingScheme struct {
+	ID            string          `json:"id"`
+	Name          string          `json:"name"`
+	Version       int             `json:"version"`
+	Description   string          `json:"description"`
+	Separator     string          `json:"separator"`
+	Case          string          `json:"case"`
+	Segments      []SchemeSegment `json:"segments"`
+	SequenceWidth int             `json:"sequenceWidth"`
+	IsActive      bool            `json:"isActive"`
+	IsDefault     bool            `json:"isDefault"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+// DecodedSegment is one segment of a decoded server name
+type DecodedSegment struct {
+	Field       string `json:"field"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// DecodedName is a server name split back into its segments
+type DecodedName struct {
+	ServerName    string           `json:"serverName"`
+	Scheme        string           `json:"scheme"`
+	SchemeVersion int              `json:"schemeVersion"`
+	Segments      []DecodedSegment `json:"segments"`
+	SequenceNum   int              `json:"sequenceNum"`
+	Reservation   *Reservation     `json:"reservation,omitempty"`
+}
+
+// DefaultNamingScheme returns the built-in compact scheme
+// (UnitCode+Type+Provider+Region+Environment+Function+Sequence, no separators).
+// It is used when the database does not define a default scheme.
+func DefaultNamingScheme() *NamingScheme {
+	return &NamingScheme{
+		Name:    DefaultSchemeName,
+		Version: 1,
+		Case:    CaseUpper,
+		Segments: []SchemeSegment{
+			{Field: SegmentUnitCode, Length: 3, Charset: CharsetAlphanumeric, Default: "SRV"},
+			{Field: SegmentType, Length: 1, Charset: CharsetAlphanumeric, Default: "V"},        // V for VM
+			{Field: SegmentProvider, Length: 1, Charset: CharsetAlphanumeric, Default: "X"},    // X for Mixed
+			{Field: SegmentRegion, Length: 4, Charset: CharsetAlphanumeric, Default: "GLBL"},   // GLBL for Global
+			{Field: SegmentEnvironment, Length: 1, Charset: CharsetAlphanumeric, Default: "P"}, // P for Production
+			{Field: SegmentFunction, Length: 2, Charset: CharsetAlphanumeric, Default: "SV"},   // SV for Server
+		},
+		SequenceWidth: 3,
+		IsActive:      true,
+		IsDefault:     true,
+	}
+}
+
+// Segment returns the scheme's definition for a field, or nil if the scheme does not use it
+func (s *NamingScheme) Segment(field string) *SchemeSegment {
+	for i := range s.Segments {
+		if s.Segments[i].Field == field {
+			return &s.Segments[i]
+		}
+	}
+	return nil
+}
+
+// Validate checks the scheme definition for internal consistency
+func (s *NamingScheme) Validate() error {
+	if len(s.Segments) == 0 {
+		return errors.New("scheme must define at least one segment")
+	}
+
+	seen := make(map[string]bool, len(s.Segments))
+	for _, seg := range s.Segments {
+		if seen[seg.Field] {
+			return fmt.Errorf("segment %s is defined more than once", seg.Field)
+		}
+		seen[seg.Field] = true
+
+		if len(seg.Default) > seg.Length {
+			return fmt.Errorf("default for segment %s is longer than %d characters", seg.Field, seg.Length)
+		}
+	}
+
+	if s.Case != CaseUpper && s.Case != CaseLower {
+		return fmt.Errorf("case must be %q or %q", CaseUpper, CaseLower)
+	}
+
+	if s.SequenceWidth < 1 || s.SequenceWidth > 9 {
+		return errors.New("sequenceWidth must be between 1 and 9")
+	}
+
+	return nil
+}
+
+// SharesSequences reports whether the two schemes can produce the same
+// sequence key. A key holds every segment field, left empty when a scheme
+// does not use it, so a segment with a default that only one scheme uses
+// keeps their keys apart.
+func (s *NamingScheme) SharesSequences(other *NamingScheme) bool {
+	for _, pair := range [][2]*NamingScheme{{s, other}, {other, s}} {
+		for _, seg := range pair[0].Segments {
+			if seg.Default != "" && pair[1].Segment(seg.Field) == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NamingSchemeModel handles database operations for naming schemes
+type NamingSchemeModel struct {
+	DB *sql.DB
+}
+
+// NewNamingSchemeModel creates a new naming scheme model
+func NewNamingSchemeModel(db *sql.DB) *NamingSchemeModel {
+	return &NamingSchemeModel{DB: db}
+}
+
+const namingSchemeColumns = `
+	id, name, version, description, separator, letter_case, segments,
+	sequence_width, is_active, is_default, created_at
+`
+
+// scanNamingScheme scans a single naming scheme row
+func scanNamingScheme(row interface{ Scan(...any) error }) (*NamingScheme, error) {
+	var segments []byte
+	s := &NamingScheme{}
+	err := row.Scan(
+		&s.ID,
+		&s.Name,
+		&s.Version,
+		&s.Description,
+		&s.Separator,
+		&s.Case,
+		&segments,
+		&s.SequenceWidth,
+		&s.IsActive,
+		&s.IsDefault,
+		&s.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(segments, &s.Segments); err != nil {
+		return nil, fmt.Errorf("failed to decode segments for scheme %s: %w", s.Name, err)
+	}
+
+	return s, nil
+}
+
+// Create inserts a new version of a naming scheme. The version number is
+// assigned from the highest existing version with the same name.
+func (m *NamingSchemeModel) Create(ctx context.Context, tx *sql.Tx, s *NamingScheme) error {
+	segments, err := json.Marshal(s.Segments)
+	if err != nil {
+		return fmt.Errorf("failed to encode segments: %w", err)
+	}
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(MAX(version), 0) + 1 FROM naming_schemes WHERE name = $1`,
+		s.Name,
+	).Scan(&s.Version)
+	if err != nil {
+		return fmt.Errorf("failed to determine scheme version: %w", err)
+	}
+
+	// Only one scheme can be the default at a time
+	if s.IsDefault {
+		if _, err := tx.ExecContext(ctx, `UPDATE naming_schemes SET is_default = false WHERE is_default`); err != nil {
+			return fmt.Errorf("failed to clear default scheme: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO naming_schemes (
+			id, name, version, description, separator, letter_case, segments,
+			sequence_width, is_active, is_default, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		)
+	`
+
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		s.ID,
+		s.Name,
+		s.Version,
+		s.Description,
+		s.Separator,
+		s.Case,
+		segments,
+		s.SequenceWidth,
+		s.IsActive,
+		s.IsDefault,
+		s.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create naming scheme: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a naming scheme by its ID
+func (m *NamingSchemeModel) GetByID(ctx context.Context, id string) (*NamingScheme, error) {
+	query := `SELECT ` + namingSchemeColumns + ` FROM naming_schemes WHERE id = $1`
+
+	s, err := scanNamingScheme(m.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetByName retrieves an active naming scheme by name. A version of 0 selects
+// the latest active version.
+func (m *NamingSchemeModel) GetByName(ctx context.Context, name string, version int) (*NamingScheme, error) {
+	query := `
+		SELECT ` + namingSchemeColumns + `
+		FROM naming_schemes
+		WHERE name = $1 AND is_active AND ($2 = 0 OR version = $2)
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	s, err := scanNamingScheme(m.DB.QueryRowContext(ctx, query, name, version))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetDefault retrieves the default naming scheme
+func (m *NamingSchemeModel) GetDefault(ctx context.Context) (*NamingScheme, error) {
+	query := `
+		SELECT ` + namingSchemeColumns + `
+		FROM naming_schemes
+		WHERE is_default AND is_active
+		LIMIT 1
+	`
+
+	s, err := scanNamingScheme(m.DB.QueryRowContext(ctx, query))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetAll retrieves every version of every naming scheme
+func (m *NamingSchemeModel) GetAll(ctx context.Context) ([]*NamingScheme, error) {
+	query := `
+		SELECT ` + namingSchemeColumns + `
+		FROM naming_schemes
+		ORDER BY name, version DESC
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query naming schemes: %w", err)
+	}
+	defer rows.Close()
+
+	var schemes []*NamingScheme
+	for rows.Next() {
+		s, err := scanNamingScheme(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan naming scheme: %w", err)
+		}
+		schemes = append(schemes, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating naming schemes: %w", err)
+	}
+
+	return schemes, nil
+}
+
+// Deactivate retires a naming scheme version so it can no longer be selected
+func (m *NamingSchemeModel) Deactivate(ctx context.Context, id string) error {
+	query := `
+		UPDATE naming_schemes
+		SET is_active = false, is_default = false
+		WHERE id = $1
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate naming scheme: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("naming scheme not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package models
+
+import "testing"
+
+func TestSharesSequences(t *testing.T) {
+	compact := DefaultNamingScheme()
+	segments := func(segs ...SchemeSegment) *NamingScheme {
+		return &NamingScheme{Segments: segs}
+	}
+
+	tests := []struct {
+		name  string
+		other *NamingScheme
+		want  bool
+	}{
+		{"same scheme", DefaultNamingScheme(), true},
+		{"missing segments with defaults", segments(
+			SchemeSegment{Field: SegmentUnitCode, Length: 3},
+			SchemeSegment{Field: SegmentEnvironment, Length: 1},
+		), false},
+		{"different lengths", segments(
+			SchemeSegment{Field: SegmentUnitCode, Length: 5, Default: "SRV"},
+			SchemeSegment{Field: SegmentType, Length: 2, Default: "V"},
+			SchemeSegment{Field: SegmentProvider, Length: 1, Default: "X"},
+			SchemeSegment{Field: SegmentRegion, Length: 3, Default: "GLB"},
+			SchemeSegment{Field: SegmentEnvironment, Length: 1, Default: "P"},
+			SchemeSegment{Field: SegmentFunction, Length: 2, Default: "SV"},
+		), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compact.SharesSequences(tt.other); got != tt.want {
+				t.Errorf("SharesSequences() = %v, want %v", got, tt.want)
+			}
+			if got := tt.other.SharesSequences(compact); got != tt.want {
+				t.Errorf("SharesSequences() reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Segments without a default can be left empty, as if the scheme lacked them
+	short := segments(SchemeSegment{Field: SegmentUnitCode, Length: 3})
+	optional := segments(
+		SchemeSegment{Field: SegmentUnitCode, Length: 3},
+		SchemeSegment{Field: SegmentFunction, Length: 2},
+	)
+	if !short.SharesSequences(optional) {
+		t.Error("SharesSequences() = false for a scheme adding an optional segment, want true")
+	}
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// OutboxMessage is a domain event waiting to be published. AggregateID is
+// the reservation the event belongs to; messages of one reservation are
+// published in ID order.
+type OutboxMessage struct {
+	ID          int64           `json:"id"`
+	AggregateID string          `json:"aggregateId"`
+	EventType   string          `json:"eventType"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"lastError,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// OutboxModel handles database operations for the transactional outbox
+type OutboxModel struct {
+	DB *sql.DB
+}
+
+// NewOutboxModel creates a new outbox model
+func NewOutboxModel(db *sql.DB) *OutboxModel {
+	return &OutboxModel{DB: db}
+}
+
+// Append writes an event to the outbox inside the transaction that makes the
+// change it describes
+func (m *OutboxModel) Append(ctx context.Context, tx *sql.Tx, e *ReservationEvent) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox payload: %w", err)
+	}
+
+	query := `
+		INSERT INTO outbox (aggregate_id, event_type, payload, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $4)
+	`
+
+	if _, err := tx.ExecContext(ctx, query, e.ReservationID, e.Type, payload, e.CreatedAt); err != nil {
+		return fmt.Errorf("failed to append to outbox: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimHeads leases up to limit messages that are due and are the oldest
+// pending message of their reservation, in ID order. Later messages of a
+// reservation are not returned until the earlier ones are published or dead
+// lettered, which keeps each reservation's events in order. A leased message
+// is not claimed again until the lease ends, so several relays can run at
+// once and a relay that dies mid-batch only delays its messages.
+func (m *OutboxModel) ClaimHeads(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*OutboxMessage, error) {
+	query := `
+		UPDATE outbox SET locked_until = $2
+		WHERE id IN (
+			SELECT o.id
+			FROM outbox o
+			WHERE o.published_at IS NULL
+			  AND o.dead_lettered_at IS NULL
+			  AND o.next_attempt_at <= $1
+			  AND (o.locked_until IS NULL OR o.locked_until <= $1)
+			  AND NOT EXISTS (
+				SELECT 1 FROM outbox p
+				WHERE p.aggregate_id = o.aggregate_id
+				  AND p.published_at IS NULL
+				  AND p.dead_lettered_at IS NULL
+				  AND p.id < o.id
+			  )
+			ORDER BY o.id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, aggregate_id, event_type, payload, attempts, last_error, created_at
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*OutboxMessage
+	for rows.Next() {
+		var lastError sql.NullString
+		var payload []byte
+		msg := &OutboxMessage{}
+		err := rows.Scan(
+			&msg.ID,
+			&msg.AggregateID,
+			&msg.EventType,
+			&payload,
+			&msg.Attempts,
+			&lastError,
+			&msg.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		msg.Payload = json.RawMessage(payload)
+		msg.LastError = lastError.String
+		messages = append(messages, msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox: %w", err)
+	}
+
+	// RETURNING does not keep the subquery's order
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+
+	return messages, nil
+}
+
+// MarkPublished records that a message reached every sink
+func (m *OutboxModel) MarkPublished(ctx context.Context, id int64, now time.Time) error {
+	query := `
+		UPDATE outbox
+		SET published_at = $1, attempts = attempts + 1, last_error = NULL, locked_until = NULL
+		WHERE id = $2
+	`
+
+	if _, err := m.DB.ExecContext(ctx, query, now, id); err != nil {
+		return fmt.Errorf("failed to mark outbox message published: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed publish. The message is retried at
+// nextAttemptAt, or dead lettered when it has failed maxAttempts times.
+// It reports whether the message was dead lettered.
+func (m *OutboxModel) MarkFailed(ctx context.Context, id int64, lastError string, now, nextAttemptAt time.Time, maxAttempts int) (bool, error) {
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1,
+		    last_error = $1,
+		    next_attempt_at = $2,
+		    locked_until = NULL,
+		    dead_lettered_at = CASE WHEN attempts + 1 >= $3 THEN $4::timestamptz END
+		WHERE id = $5
+		RETURNING dead_lettered_at IS NOT NULL
+	`
+
+	var deadLettered bool
+	err := m.DB.QueryRowContext(ctx, query, lastError, nextAttemptAt, maxAttempts, now, id).Scan(&deadLettered)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+	return deadLettered, nil
+}
+
+// Release ends the leases of messages that were claimed but not attempted
+func (m *OutboxModel) Release(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := m.DB.ExecContext(ctx, `UPDATE outbox SET locked_until = NULL WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to release outbox messages: %w", err)
+	}
+	return nil
+}
+
+// DeletePublished removes messages published before a cut-off and returns
+// how many were removed
+func (m *OutboxModel) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM outbox WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox: %w", err)
+	}
+	return result.RowsAffected()
+}
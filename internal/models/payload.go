@@ -1,21 +1,142 @@
 // File: internal/models/payload.go
 package models
 
-// ReservationPayload represents the request payload for reserving a server name
+// ReservationPayload represents the request payload for reserving a server name.
+// Segment lengths are enforced by the selected naming scheme.
 type ReservationPayload struct {
-	UnitCode    string `json:"unitCode,omitempty" validate:"omitempty,max=3"`
-	Type        string `json:"type,omitempty" validate:"omitempty,max=1"`
-	Provider    string `json:"provider,omitempty" validate:"omitempty,max=1"`
-	Region      string `json:"region,omitempty" validate:"omitempty,max=4"`
-	Environment string `json:"environment,omitempty" validate:"omitempty,max=1"`
-	Function    string `json:"function,omitempty" validate:"omitempty,max=2"`
+	UnitCode      string `json:"unitCode,omitempty" validate:"omitempty,max=10"`
+	Type          string `json:"type,omitempty" validate:"omitempty,max=10"`
+	Provider      string `json:"provider,omitempty" validate:"omitempty,max=10"`
+	Region        string `json:"region,omitempty" validate:"omitempty,max=10"`
+	Environment   string `json:"environment,omitempty" validate:"omitempty,max=10"`
+	Function      string `json:"function,omitempty" validate:"omitempty,max=10"`
+	Scheme        string `json:"scheme,omitempty" validate:"omitempty,max=50"`
+	SchemeVersion int    `json:"schemeVersion,omitempty" validate:"omitempty,min=1"`
+	TTLSeconds    int    `json:"ttlSeconds,omitempty" validate:"omitempty,min=1"`
+	ReservationDetails
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// SegmentValue returns the payload value for a naming scheme segment field
+func (p ReservationPayload) SegmentValue(field string) string {
+	switch field {
+	case SegmentUnitCode:
+		return p.UnitCode
+	case SegmentType:
+		return p.Type
+	case SegmentProvider:
+		return p.Provider
+	case SegmentRegion:
+		return p.Region
+	case SegmentEnvironment:
+		return p.Environment
+	case SegmentFunction:
+		return p.Function
+	default:
+		return ""
+	}
+}
+
+// SetSegmentValue sets the payload value for a naming scheme segment field
+func (p *ReservationPayload) SetSegmentValue(field, value string) {
+	switch field {
+	case SegmentUnitCode:
+		p.UnitCode = value
+	case SegmentType:
+		p.Type = value
+	case SegmentProvider:
+		p.Provider = value
+	case SegmentRegion:
+		p.Region = value
+	case SegmentEnvironment:
+		p.Environment = value
+	case SegmentFunction:
+		p.Function = value
+	}
+}
+
+// ExplicitReservationPayload represents the request payload for claiming a specific server name
+type ExplicitReservationPayload struct {
+	ServerName    string `json:"serverName" validate:"required,max=100"`
+	Scheme        string `json:"scheme,omitempty" validate:"omitempty,max=50"`
+	SchemeVersion int    `json:"schemeVersion,omitempty" validate:"omitempty,min=1"`
+	TTLSeconds    int    `json:"ttlSeconds,omitempty" validate:"omitempty,min=1"`
+	ReservationDetails
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// MaxBatchSize is the largest number of names a single batch reservation may request
+const MaxBatchSize = 100
+
+// BatchReservationPayload represents the request payload for reserving several names at once.
+// Either Payload with Count, or a list of Reservations, must be given.
+type BatchReservationPayload struct {
+	Payload      *ReservationPayload  `json:"payload,omitempty"`
+	Count        int                  `json:"count,omitempty" validate:"omitempty,min=1,max=100"`
+	Reservations []ReservationPayload `json:"reservations,omitempty" validate:"omitempty,max=100,dive"`
+}
+
+// Expand returns one reservation payload per name requested by the batch
+func (p BatchReservationPayload) Expand() []ReservationPayload {
+	if p.Payload == nil {
+		return p.Reservations
+	}
+
+	payloads := make([]ReservationPayload, p.Count)
+	for i := range payloads {
+		payloads[i] = *p.Payload
+	}
+	return payloads
+}
+
+// NamingSchemePayload represents the request payload for creating a naming scheme version
+type NamingSchemePayload struct {
+	Name          string          `json:"name" validate:"required,max=50"`
+	Description   string          `json:"description,omitempty"`
+	Separator     string          `json:"separator,omitempty" validate:"omitempty,max=5"`
+	Case          string          `json:"case,omitempty" validate:"omitempty,oneof=upper lower"`
+	Segments      []SchemeSegment `json:"segments" validate:"required,min=1,dive"`
+	SequenceWidth int             `json:"sequenceWidth" validate:"required,min=1,max=9"`
+	IsDefault     bool            `json:"isDefault,omitempty"`
+}
+
+// CatalogValuePayload represents the request payload for creating or updating a catalog value
+type CatalogValuePayload struct {
+	Code        string `json:"code" validate:"required,alphanum,max=10"`
+	Description string `json:"description,omitempty" validate:"omitempty,max=255"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+}
+
+// SequencePayload represents the request payload for seeding or resetting a sequence counter
+type SequencePayload struct {
+	SequenceKey
+	Value int `json:"value" validate:"min=0"`
+}
+
+// SequenceSettingsPayload represents the request payload for changing sequence allocation settings.
+// Omitted settings are left unchanged.
+type SequenceSettingsPayload struct {
+	SequenceKey
+	OverflowPolicy       *string `json:"overflowPolicy,omitempty" validate:"omitempty,oneof=reject reuse alphabet"`
+	AllocationMode       *string `json:"allocationMode,omitempty" validate:"omitempty,oneof=increment gap_fill"`
+	ReuseCooldownSeconds *int    `json:"reuseCooldownSeconds,omitempty" validate:"omitempty,min=0"`
 }
 
 // CommitPayload represents the request payload for committing a reservation
 type CommitPayload struct {
-	ReservationID string `json:"reservationId" validate:"required,uuid"`
+	ReservationID string            `json:"reservationId" validate:"required,uuid"`
+	Tags          map[string]string `json:"tags,omitempty"`
 }
 
+// DecommissionPayload represents the optional request payload for decommissioning a name
+type DecommissionPayload struct {
+	QuarantineSeconds int `json:"quarantineSeconds,omitempty" validate:"omitempty,min=1"`
+}
+
+// TagsPatchPayload represents the request payload for editing reservation tags.
+// It follows JSON merge patch: a string sets a tag and null removes it.
+type TagsPatchPayload map[string]*string
+
 // ReleasePayload represents the request payload for releasing a reservation
 type ReleasePayload struct {
 	ReservationID string `json:"reservationId" validate:"required,uuid"`
@@ -3,38 +3,78 @@ package models
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 // Reservation statuses
 const (
 	StatusReserved  = "reserved"
 	StatusCommitted = "committed"
+	StatusExpired   = "expired"
+
+	// StatusDecommissioned names are out of service and quarantined until
+	// QuarantineUntil, after which they are reclaimed for reuse
+	StatusDecommissioned = "decommissioned"
+
+	// StatusRetired names are never reused
+	StatusRetired = "retired"
 )
 
 // Reservation represents a server name reservation in the database
 type Reservation struct {
-	ID          string    `json:"id"`
-	ServerName  string    `json:"serverName"`
-	UnitCode    string    `json:"unitCode"`
-	Type        string    `json:"type"`
-	Provider    string    `json:"provider"`
-	Region      string    `json:"region"`
-	Environment string    `json:"environment"`
-	Function    string    `json:"function"`
-	SequenceNum int       `json:"sequenceNum"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          string     `json:"id"`
+	ServerName  string     `json:"serverName"`
+	UnitCode    string     `json:"unitCode"`
+	Type        string     `json:"type"`
+	Provider    string     `json:"provider"`
+	Region      string     `json:"region"`
+	Environment string     `json:"environment"`
+	Function    string     `json:"function"`
+	SequenceNum int        `json:"sequenceNum"`
+	Status      string     `json:"status"`
+	SchemeID    string     `json:"schemeId,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CommittedAt *time.Time `json:"committedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+
+	QuarantineUntil *time.Time `json:"quarantineUntil,omitempty"`
+
+	// Ownership
+	CreatedBy   string `json:"createdBy,omitempty"`
+	CommittedBy string `json:"committedBy,omitempty"`
+	APIKeyID    string `json:"apiKeyId,omitempty"`
+	ReservationDetails
+
+	Tags map[string]string `json:"tags"`
+}
+
+// ReservationDetails is free-form information supplied by the requester
+type ReservationDetails struct {
+	Description  string `json:"description,omitempty" validate:"omitempty,max=500"`
+	Ticket       string `json:"ticket,omitempty" validate:"omitempty,max=100"`
+	RequestedFor string `json:"requestedFor,omitempty" validate:"omitempty,max=100"`
 }
 
 // ReservationResponse is the API response for reservation operations
 type ReservationResponse struct {
-	ReservationID string `json:"reservationId"`
-	ServerName    string `json:"serverName"`
+	ReservationID string     `json:"reservationId"`
+	ServerName    string     `json:"serverName"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+}
+
+// PreviewResponse is the API response for a name preview
+type PreviewResponse struct {
+	ServerName    string             `json:"serverName"`
+	SequenceNum   int                `json:"sequenceNum"`
+	Scheme        string             `json:"scheme"`
+	SchemeVersion int                `json:"schemeVersion"`
+	Fields        ReservationPayload `json:"fields"`
 }
 
 // ReservationModel handles database operations for reservations
@@ -47,18 +87,98 @@ func NewReservationModel(db *sql.DB) *ReservationModel {
 	return &ReservationModel{DB: db}
 }
 
+const reservationColumns = `
+	id, server_name, unit_code, type, provider, region, environment, function,
+	sequence_num, status, scheme_id, created_at, updated_at, committed_at, expires_at,
+	created_by, committed_by, api_key_id, description, ticket, requested_for, tags,
+	quarantine_until
+`
+
+// scanReservation scans a single reservation row selected with reservationColumns
+func scanReservation(row interface{ Scan(...any) error }) (*Reservation, error) {
+	var schemeID sql.NullString
+	var committedAt, expiresAt, quarantineUntil sql.NullTime
+	var createdBy, committedBy, apiKeyID sql.NullString
+	var tags []byte
+	r := &Reservation{}
+	err := row.Scan(
+		&r.ID,
+		&r.ServerName,
+		&r.UnitCode,
+		&r.Type,
+		&r.Provider,
+		&r.Region,
+		&r.Environment,
+		&r.Function,
+		&r.SequenceNum,
+		&r.Status,
+		&schemeID,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&committedAt,
+		&expiresAt,
+		&createdBy,
+		&committedBy,
+		&apiKeyID,
+		&r.Description,
+		&r.Ticket,
+		&r.RequestedFor,
+		&tags,
+		&quarantineUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	r.SchemeID = schemeID.String
+	r.CreatedBy = createdBy.String
+	r.CommittedBy = committedBy.String
+	r.APIKeyID = apiKeyID.String
+	if err := json.Unmarshal(tags, &r.Tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags: %w", err)
+	}
+	if r.Tags == nil {
+		r.Tags = map[string]string{}
+	}
+	if committedAt.Valid {
+		r.CommittedAt = &committedAt.Time
+	}
+	if expiresAt.Valid {
+		r.ExpiresAt = &expiresAt.Time
+	}
+	if quarantineUntil.Valid {
+		r.QuarantineUntil = &quarantineUntil.Time
+	}
+	return r, nil
+}
+
+// nullIfEmpty maps an empty string to a NULL query parameter
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
 // Create inserts a new reservation into the database
 func (m *ReservationModel) Create(ctx context.Context, tx *sql.Tx, r *Reservation) error {
 	query := `
 		INSERT INTO reservations (
 			id, server_name, unit_code, type, provider, region, environment, function, 
-			sequence_num, status, created_at, updated_at
+			sequence_num, status, scheme_id, created_at, updated_at, expires_at,
+			created_by, api_key_id, description, ticket, requested_for, tags
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+			$15, $16, $17, $18, $19, $20
 		)
 	`
 
-	_, err := tx.ExecContext(
+	tags, err := marshalTags(r.Tags)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
 		ctx,
 		query,
 		r.ID,
@@ -71,38 +191,72 @@ func (m *ReservationModel) Create(ctx context.Context, tx *sql.Tx, r *Reservatio
 		r.Function,
 		r.SequenceNum,
 		r.Status,
+		nullIfEmpty(r.SchemeID),
 		r.CreatedAt,
 		r.UpdatedAt,
+		r.ExpiresAt,
+		nullIfEmpty(r.CreatedBy),
+		nullIfEmpty(r.APIKeyID),
+		r.Description,
+		r.Ticket,
+		r.RequestedFor,
+		tags,
 	)
 
 	return err
 }
 
+// marshalTags encodes tags for a JSONB column
+func marshalTags(tags map[string]string) ([]byte, error) {
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tags: %w", err)
+	}
+	return data, nil
+}
+
 // GetByID retrieves a reservation by its ID
 func (m *ReservationModel) GetByID(ctx context.Context, id string) (*Reservation, error) {
-	query := `
-		SELECT id, server_name, unit_code, type, provider, region, environment, function,
-			   sequence_num, status, created_at, updated_at
-		FROM reservations
-		WHERE id = $1
-	`
+	query := `SELECT ` + reservationColumns + ` FROM reservations WHERE id = $1`
 
-	r := &Reservation{}
-	err := m.DB.QueryRowContext(ctx, query, id).Scan(
-		&r.ID,
-		&r.ServerName,
-		&r.UnitCode,
-		&r.Type,
-		&r.Provider,
-		&r.Region,
-		&r.Environment,
-		&r.Function,
-		&r.SequenceNum,
-		&r.Status,
-		&r.CreatedAt,
-		&r.UpdatedAt,
-	)
+	r, err := scanReservation(m.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetByIDForUpdate retrieves a reservation by its ID and locks it for the rest of tx
+func (m *ReservationModel) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id string) (*Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations WHERE id = $1 FOR UPDATE`
+
+	r, err := scanReservation(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetByServerName retrieves the reservation holding a server name, or the most
+// recent expired one when none holds it. The comparison ignores case.
+func (m *ReservationModel) GetByServerName(ctx context.Context, serverName string) (*Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations
+		WHERE UPPER(server_name) = UPPER($1)
+		ORDER BY status = $2, created_at DESC
+		LIMIT 1`
 
+	r, err := scanReservation(m.DB.QueryRowContext(ctx, query, serverName, StatusExpired))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -113,11 +267,46 @@ func (m *ReservationModel) GetByID(ctx context.Context, id string) (*Reservation
 	return r, nil
 }
 
+// GetAll retrieves all reservations, newest first
+func (m *ReservationModel) GetAll(ctx context.Context) ([]*Reservation, error) {
+	return m.query(ctx, `SELECT `+reservationColumns+` FROM reservations ORDER BY created_at DESC`)
+}
+
+// GetRecent retrieves the most recently created reservations
+func (m *ReservationModel) GetRecent(ctx context.Context, limit int) ([]*Reservation, error) {
+	return m.query(ctx, `SELECT `+reservationColumns+` FROM reservations ORDER BY created_at DESC LIMIT $1`, limit)
+}
+
+// query runs a reservation SELECT and scans every row
+func (m *ReservationModel) query(ctx context.Context, query string, args ...any) ([]*Reservation, error) {
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var reservations []*Reservation
+	for rows.Next() {
+		r, err := scanReservation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		reservations = append(reservations, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
 // UpdateStatus updates the status of a reservation
 func (m *ReservationModel) UpdateStatus(ctx context.Context, tx *sql.Tx, id, status string) error {
 	query := `
 		UPDATE reservations
-		SET status = $1, updated_at = $2
+		SET status = $1, updated_at = $2,
+			committed_at = CASE WHEN $1 = $4 THEN $2 ELSE committed_at END
 		WHERE id = $3 AND status != $4
 	`
 
@@ -145,18 +334,134 @@ func (m *ReservationModel) UpdateStatus(ctx context.Context, tx *sql.Tx, id, sta
 	return nil
 }
 
-// IsServerNameUnique checks if a server name is already in use
+// Commit marks a reservation as committed by a principal and merges tags
+// into its existing tags
+func (m *ReservationModel) Commit(ctx context.Context, tx *sql.Tx, id, committedBy string, tags map[string]string) error {
+	query := `
+		UPDATE reservations
+		SET status = $1, updated_at = $2, committed_at = $2, committed_by = $3,
+			tags = tags || $5::jsonb
+		WHERE id = $4 AND status != $1
+	`
+
+	encoded, err := marshalTags(tags)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, StatusCommitted, time.Now().UTC(), nullIfEmpty(committedBy), id, encoded)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("reservation not found or already committed")
+	}
+
+	return nil
+}
+
+// SetLifecycleStatus moves a reservation from one status to another and sets
+// its quarantine end, which is nil outside the decommissioned status
+func (m *ReservationModel) SetLifecycleStatus(ctx context.Context, tx *sql.Tx, id, from, to string, quarantineUntil *time.Time) error {
+	query := `
+		UPDATE reservations
+		SET status = $1, quarantine_until = $2, updated_at = $3
+		WHERE id = $4 AND status = $5
+	`
+
+	result, err := tx.ExecContext(ctx, query, to, quarantineUntil, time.Now().UTC(), id, from)
+	if err != nil {
+		return fmt.Errorf("failed to update reservation status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("reservation is no longer %s", from)
+	}
+
+	return nil
+}
+
+// FindQuarantineEnded returns up to limit decommissioned reservations whose
+// quarantine has ended
+func (m *ReservationModel) FindQuarantineEnded(ctx context.Context, tx *sql.Tx, now time.Time, limit int) ([]*Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations
+		WHERE status = $1 AND (quarantine_until IS NULL OR quarantine_until <= $2)
+		ORDER BY quarantine_until
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.QueryContext(ctx, query, StatusDecommissioned, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quarantined reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var reservations []*Reservation
+	for rows.Next() {
+		r, err := scanReservation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		reservations = append(reservations, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating quarantined reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// UpdateTags sets and removes tags on a reservation and returns the updated reservation.
+// Removals are applied after additions.
+func (m *ReservationModel) UpdateTags(ctx context.Context, tx *sql.Tx, id string, set map[string]string, remove []string) (*Reservation, error) {
+	query := `
+		UPDATE reservations
+		SET tags = (tags || $1::jsonb) - $2::text[], updated_at = $3
+		WHERE id = $4
+		RETURNING ` + reservationColumns
+
+	encoded, err := marshalTags(set)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := scanReservation(tx.QueryRowContext(ctx, query, encoded, pq.Array(remove), time.Now().UTC(), id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to update tags: %w", err)
+	}
+
+	return r, nil
+}
+
+// IsServerNameUnique checks if a server name is already in use. Names held by
+// committed, decommissioned (quarantined) or retired reservations are in use.
 func (m *ReservationModel) IsServerNameUnique(ctx context.Context, tx *sql.Tx, serverName string) (bool, error) {
 	query := `
 		SELECT EXISTS(
 			SELECT 1 
 			FROM reservations 
-			WHERE server_name = $1 AND status = $2
+			WHERE server_name = $1 AND status = ANY($2)
 		)
 	`
 
+	held := []string{StatusCommitted, StatusDecommissioned, StatusRetired}
 	var exists bool
-	err := tx.QueryRowContext(ctx, query, serverName, StatusCommitted).Scan(&exists)
+	err := tx.QueryRowContext(ctx, query, serverName, pq.Array(held)).Scan(&exists)
 	if err != nil {
 		return false, err
 	}
@@ -164,20 +469,43 @@ func (m *ReservationModel) IsServerNameUnique(ctx context.Context, tx *sql.Tx, s
 	return !exists, nil
 }
 
-// FindLatestSequenceNumber finds the latest sequence number for a similar name pattern
-func (m *ReservationModel) FindLatestSequenceNumber(ctx context.Context, tx *sql.Tx, pattern string) (int, error) {
-	// Escape any special characters in the pattern
-	pattern = strings.ReplaceAll(pattern, "%", "\\%")
-	pattern = strings.ReplaceAll(pattern, "_", "\\_")
+// IsServerNameTaken reports whether a reservation in any status other than
+// expired holds serverName. Expired reservations give up their names.
+func (m *ReservationModel) IsServerNameTaken(ctx context.Context, tx *sql.Tx, serverName string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM reservations WHERE server_name = $1 AND status <> $2)`
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, query, serverName, StatusExpired).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
 
+// FindHighestSequenceForKey finds the highest sequence number reserved for a sequence key
+func (m *ReservationModel) FindHighestSequenceForKey(ctx context.Context, tx *sql.Tx, key SequenceKey) (int, error) {
 	query := `
 		SELECT MAX(sequence_num)
 		FROM reservations
-		WHERE server_name LIKE $1 || '%'
+		WHERE unit_code = $1
+		  AND type = $2
+		  AND provider = $3
+		  AND region = $4
+		  AND environment = $5
+		  AND function = $6
 	`
 
 	var maxSequence sql.NullInt64
-	err := tx.QueryRowContext(ctx, query, pattern).Scan(&maxSequence)
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		key.UnitCode,
+		key.Type,
+		key.Provider,
+		key.Region,
+		key.Environment,
+		key.Function,
+	).Scan(&maxSequence)
 	if err != nil {
 		return 0, err
 	}
@@ -189,6 +517,132 @@ func (m *ReservationModel) FindLatestSequenceNumber(ctx context.Context, tx *sql
 	return int(maxSequence.Int64), nil
 }
 
+// FindLowestFreeSequence finds the lowest number between 1 and max that no
+// unexpired reservation for the key holds. Numbers of deleted names that were committed
+// within the cool-down period are skipped. It returns 0 when every number is
+// taken.
+func (m *ReservationModel) FindLowestFreeSequence(ctx context.Context, tx *sql.Tx, key SequenceKey, max int, cooldown time.Duration) (int, error) {
+	query := `
+		SELECT n
+		FROM generate_series(1, $7::int) AS n
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM reservations
+			WHERE unit_code = $1
+			  AND type = $2
+			  AND provider = $3
+			  AND region = $4
+			  AND environment = $5
+			  AND function = $6
+			  AND sequence_num = n
+			  AND status <> $9
+		)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM sequence_tombstones
+			WHERE unit_code = $1
+			  AND type = $2
+			  AND provider = $3
+			  AND region = $4
+			  AND environment = $5
+			  AND function = $6
+			  AND sequence_num = n
+			  AND last_committed_at > $8
+		)
+		ORDER BY n
+		LIMIT 1
+	`
+
+	var free int
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		key.UnitCode,
+		key.Type,
+		key.Provider,
+		key.Region,
+		key.Environment,
+		key.Function,
+		max,
+		time.Now().UTC().Add(-cooldown),
+		StatusExpired,
+	).Scan(&free)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return free, nil
+}
+
+// PrefixUsage summarizes how many sequence numbers are held for a key.
+// SchemeID is the scheme of the key's newest reservation.
+type PrefixUsage struct {
+	SchemeID       string
+	Key            SequenceKey
+	Used           int
+	Highest        int
+	OverflowPolicy string
+}
+
+// GetPrefixUsage counts unexpired reservations per sequence key. Every
+// scheme that renders a key draws from its one counter, so reservations
+// made under older schemes are counted together with newer ones.
+func (m *ReservationModel) GetPrefixUsage(ctx context.Context) ([]*PrefixUsage, error) {
+	query := `
+		SELECT (ARRAY_AGG(r.scheme_id ORDER BY r.created_at DESC))[1],
+			   r.unit_code, r.type, r.provider, r.region, r.environment, r.function,
+			   COUNT(*), MAX(r.sequence_num), COALESCE(MAX(s.overflow_policy), $1)
+		FROM reservations r
+		LEFT JOIN sequences s
+		  ON s.unit_code = r.unit_code
+		 AND s.type = r.type
+		 AND s.provider = r.provider
+		 AND s.region = r.region
+		 AND s.environment = r.environment
+		 AND s.function = r.function
+		WHERE r.status <> $2
+		GROUP BY r.unit_code, r.type, r.provider, r.region, r.environment, r.function
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, OverflowReject, StatusExpired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prefix usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []*PrefixUsage
+	for rows.Next() {
+		var schemeID sql.NullString
+		u := &PrefixUsage{}
+		err := rows.Scan(
+			&schemeID,
+			&u.Key.UnitCode,
+			&u.Key.Type,
+			&u.Key.Provider,
+			&u.Key.Region,
+			&u.Key.Environment,
+			&u.Key.Function,
+			&u.Used,
+			&u.Highest,
+			&u.OverflowPolicy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prefix usage: %w", err)
+		}
+		u.SchemeID = schemeID.String
+		usage = append(usage, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prefix usage: %w", err)
+	}
+
+	return usage, nil
+}
+
 // Delete deletes a reservation by ID (works for any status)
 func (m *ReservationModel) Delete(ctx context.Context, tx *sql.Tx, id string) error {
 	query := `
@@ -213,16 +667,17 @@ func (m *ReservationModel) Delete(ctx context.Context, tx *sql.Tx, id string) er
 	return nil
 }
 
-func (m *ReservationModel) Release(ctx context.Context, tx *sql.Tx, id string) error {
+// Release moves a committed reservation back to reserved with a new expiry
+func (m *ReservationModel) Release(ctx context.Context, tx *sql.Tx, id string, expiresAt *time.Time) error {
 	query := `
 		UPDATE reservations
-		SET status = 'reserved', updated_at = NOW()
+		SET status = 'reserved', updated_at = NOW(), expires_at = $2
 		WHERE id = $1 AND status = 'committed'
 		RETURNING id
 	`
 
 	var reservationID string
-	err := tx.QueryRowContext(ctx, query, id).Scan(&reservationID)
+	err := tx.QueryRowContext(ctx, query, id, expiresAt).Scan(&reservationID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return fmt.Errorf("reservation not found or not committed")
@@ -232,3 +687,40 @@ func (m *ReservationModel) Release(ctx context.Context, tx *sql.Tx, id string) e
 
 	return nil
 }
+
+// ExpireStale marks up to limit reserved reservations whose expiry has passed
+// as expired and returns them
+func (m *ReservationModel) ExpireStale(ctx context.Context, tx *sql.Tx, now time.Time, limit int) ([]*Reservation, error) {
+	query := `
+		UPDATE reservations
+		SET status = $1, updated_at = $2
+		WHERE id IN (
+			SELECT id
+			FROM reservations
+			WHERE status = $3 AND expires_at <= $2
+			ORDER BY expires_at
+			LIMIT $4
+		)
+		RETURNING ` + reservationColumns
+
+	rows, err := tx.QueryContext(ctx, query, StatusExpired, now, StatusReserved, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var expired []*Reservation
+	for rows.Next() {
+		r, err := scanReservation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		expired = append(expired, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expired reservations: %w", err)
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,323 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Reservation page size limits
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// ReservationFilter selects reservations. Empty fields match anything.
+type ReservationFilter struct {
+	// Ownership
+	CreatedBy    string
+	CommittedBy  string
+	APIKeyID     string
+	Ticket       string
+	RequestedFor string
+
+	Statuses []string
+
+	// Segments
+	UnitCode    string
+	Type        string
+	Provider    string
+	Region      string
+	Environment string
+	Function    string
+
+	// Date ranges; After is inclusive and Before exclusive
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+
+	// Server name matching, case-insensitive
+	NamePrefix   string
+	NameContains string
+
+	Tags []TagSelector
+}
+
+// where builds the SQL conditions and arguments for the filter
+func (f ReservationFilter) where() ([]string, []any) {
+	var conditions []string
+	var args []any
+	add := func(column, value string) {
+		if value == "" {
+			return
+		}
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	addTime := func(column, op string, value *time.Time) {
+		if value == nil {
+			return
+		}
+		args = append(args, *value)
+		conditions = append(conditions, fmt.Sprintf("%s %s $%d", column, op, len(args)))
+	}
+	addLike := func(pattern string) {
+		args = append(args, pattern)
+		conditions = append(conditions, fmt.Sprintf("server_name ILIKE $%d", len(args)))
+	}
+
+	add("created_by", f.CreatedBy)
+	add("committed_by", f.CommittedBy)
+	add("api_key_id", f.APIKeyID)
+	add("ticket", f.Ticket)
+	add("requested_for", f.RequestedFor)
+
+	if len(f.Statuses) > 0 {
+		args = append(args, pq.Array(f.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	add("unit_code", f.UnitCode)
+	add("type", f.Type)
+	add("provider", f.Provider)
+	add("region", f.Region)
+	add("environment", f.Environment)
+	add("function", f.Function)
+
+	addTime("created_at", ">=", f.CreatedAfter)
+	addTime("created_at", "<", f.CreatedBefore)
+	addTime("updated_at", ">=", f.UpdatedAfter)
+	addTime("updated_at", "<", f.UpdatedBefore)
+
+	if f.NamePrefix != "" {
+		addLike(escapeLike(f.NamePrefix) + "%")
+	}
+	if f.NameContains != "" {
+		addLike("%" + escapeLike(f.NameContains) + "%")
+	}
+
+	for _, sel := range f.Tags {
+		args = append(args, sel.Key)
+		keyArg := len(args)
+		switch sel.Op {
+		case TagOpExists:
+			conditions = append(conditions, fmt.Sprintf("tags ? $%d", keyArg))
+		case TagOpNotExists:
+			conditions = append(conditions, fmt.Sprintf("NOT tags ? $%d", keyArg))
+		case TagOpEquals:
+			args = append(args, sel.Value)
+			conditions = append(conditions, fmt.Sprintf("tags ->> $%d = $%d", keyArg, len(args)))
+		case TagOpNotEquals:
+			args = append(args, sel.Value)
+			conditions = append(conditions, fmt.Sprintf("(tags ->> $%d) IS DISTINCT FROM $%d", keyArg, len(args)))
+		}
+	}
+
+	return conditions, args
+}
+
+// escapeLike escapes the LIKE wildcards in a literal value
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// whereClause joins conditions into a WHERE clause
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return ` WHERE ` + strings.Join(conditions, " AND ")
+}
+
+// reservationSort describes a sortable reservation column
+type reservationSort struct {
+	column string
+	cast   string
+	value  func(r *Reservation) string
+}
+
+// reservationSorts are the columns reservations can be sorted by
+var reservationSorts = map[string]reservationSort{
+	"createdAt": {"created_at", "timestamptz", func(r *Reservation) string {
+		return r.CreatedAt.Format(time.RFC3339Nano)
+	}},
+	"updatedAt": {"updated_at", "timestamptz", func(r *Reservation) string {
+		return r.UpdatedAt.Format(time.RFC3339Nano)
+	}},
+	"serverName": {"server_name", "varchar", func(r *Reservation) string {
+		return r.ServerName
+	}},
+	"status": {"status", "varchar", func(r *Reservation) string {
+		return r.Status
+	}},
+	"sequenceNum": {"sequence_num", "integer", func(r *Reservation) string {
+		return fmt.Sprintf("%d", r.SequenceNum)
+	}},
+}
+
+// PageRequest selects one page of reservations
+type PageRequest struct {
+	Sort       string // One of createdAt, updatedAt, serverName, status, sequenceNum
+	Descending bool
+	Limit      int
+	Cursor     string // NextCursor of the previous page
+}
+
+// ReservationPage is one page of reservations
+type ReservationPage struct {
+	Reservations []*Reservation `json:"reservations"`
+	Total        int            `json:"total"`
+	NextCursor   string         `json:"nextCursor,omitempty"`
+}
+
+// pageCursor is the position after the last row of a page. It is encoded
+// as base64 JSON so clients treat it as opaque.
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (pageCursor, error) {
+	var c pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// Validate checks the sort column, limit and cursor of a page request, and
+// fills in defaults
+func (p *PageRequest) Validate() error {
+	if p.Sort == "" {
+		p.Sort = "createdAt"
+	}
+	if _, ok := reservationSorts[p.Sort]; !ok {
+		return fmt.Errorf("cannot sort by %q", p.Sort)
+	}
+
+	if p.Limit == 0 {
+		p.Limit = DefaultPageSize
+	}
+	if p.Limit < 1 || p.Limit > MaxPageSize {
+		return fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+	}
+
+	if p.Cursor != "" {
+		c, err := decodeCursor(p.Cursor)
+		if err != nil {
+			return err
+		}
+		if c.Sort != p.Sort {
+			return fmt.Errorf("cursor was issued for sort %q", c.Sort)
+		}
+	}
+
+	return nil
+}
+
+// Find retrieves every reservation matching a filter, newest first
+func (m *ReservationModel) Find(ctx context.Context, filter ReservationFilter) ([]*Reservation, error) {
+	conditions, args := filter.where()
+	query := `SELECT ` + reservationColumns + ` FROM reservations` +
+		whereClause(conditions) + ` ORDER BY created_at DESC`
+
+	return m.query(ctx, query, args...)
+}
+
+// FindPage retrieves one page of the reservations matching a filter, using
+// keyset pagination on the sort column and ID. Total counts every matching
+// reservation. The page request must have been validated.
+func (m *ReservationModel) FindPage(ctx context.Context, filter ReservationFilter, page PageRequest) (*ReservationPage, error) {
+	conditions, args := filter.where()
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM reservations` + whereClause(conditions)
+	if err := m.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count reservations: %w", err)
+	}
+
+	sort := reservationSorts[page.Sort]
+	direction, op := "ASC", ">"
+	if page.Descending {
+		direction, op = "DESC", "<"
+	}
+
+	if page.Cursor != "" {
+		c, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, c.Value, c.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d::%s, $%d::uuid)",
+			sort.column, op, len(args)-1, sort.cast, len(args)))
+	}
+
+	// Fetch one extra row to learn whether there is a next page
+	args = append(args, page.Limit+1)
+	query := `SELECT ` + reservationColumns + ` FROM reservations` + whereClause(conditions) +
+		fmt.Sprintf(` ORDER BY %s %s, id %s LIMIT $%d`, sort.column, direction, direction, len(args))
+
+	reservations, err := m.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReservationPage{Reservations: reservations, Total: total}
+	if result.Reservations == nil {
+		result.Reservations = []*Reservation{}
+	}
+	if len(reservations) > page.Limit {
+		result.Reservations = reservations[:page.Limit]
+		last := result.Reservations[page.Limit-1]
+		result.NextCursor = encodeCursor(pageCursor{Sort: page.Sort, Value: sort.value(last), ID: last.ID})
+	}
+
+	return result, nil
+}
+
+// Each calls fn for every reservation matching a filter, oldest first, reading
+// rows from the database one at a time instead of loading them all. It stops
+// at the first error returned by fn.
+func (m *ReservationModel) Each(ctx context.Context, filter ReservationFilter, fn func(*Reservation) error) error {
+	conditions, args := filter.where()
+	query := `SELECT ` + reservationColumns + ` FROM reservations` +
+		whereClause(conditions) + ` ORDER BY created_at, id`
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query reservations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanReservation(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating reservations: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/db/dbtest"
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 6, 1, 12, 30, 0, 123456789, time.UTC)
+	r := &Reservation{ID: "3f1e2d4c-0000-4000-8000-000000000001", ServerName: "ABCVAWEU1PWB007", SequenceNum: 7, CreatedAt: created}
+
+	tests := []pageCursor{
+		{Sort: "createdAt", Value: reservationSorts["createdAt"].value(r), ID: r.ID},
+		{Sort: "serverName", Value: reservationSorts["serverName"].value(r), ID: r.ID},
+		{Sort: "sequenceNum", Value: reservationSorts["sequenceNum"].value(r), ID: r.ID},
+		{Sort: "serverName", Value: `odd "quoted", value/+=`, ID: r.ID},
+		{},
+	}
+
+	for _, want := range tests {
+		encoded := encodeCursor(want)
+		got, err := decodeCursor(encoded)
+		if err != nil {
+			t.Errorf("decodeCursor(encodeCursor(%+v)) error = %v", want, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v", want, got)
+		}
+	}
+
+	if got := reservationSorts["createdAt"].value(r); got != "2024-06-01T12:30:00.123456789Z" {
+		t.Errorf("createdAt cursor value = %q, want nanosecond precision", got)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`["array"]`)),
+		base64.StdEncoding.EncodeToString([]byte(`{"s":"createdAt"}`)),
+	}
+
+	for _, value := range tests {
+		if _, err := decodeCursor(value); err == nil {
+			t.Errorf("decodeCursor(%q) succeeded, want error", value)
+		}
+	}
+}
+
+func TestPageRequestValidate(t *testing.T) {
+	cursor := encodeCursor(pageCursor{Sort: "serverName", Value: "ABC", ID: "1"})
+
+	tests := []struct {
+		name      string
+		page      PageRequest
+		wantSort  string
+		wantLimit int
+		wantErr   bool
+	}{
+		{name: "defaults", page: PageRequest{}, wantSort: "createdAt", wantLimit: DefaultPageSize},
+		{name: "explicit", page: PageRequest{Sort: "serverName", Limit: 10}, wantSort: "serverName", wantLimit: 10},
+		{name: "max limit", page: PageRequest{Limit: MaxPageSize}, wantSort: "createdAt", wantLimit: MaxPageSize},
+		{name: "limit too large", page: PageRequest{Limit: MaxPageSize + 1}, wantErr: true},
+		{name: "negative limit", page: PageRequest{Limit: -1}, wantErr: true},
+		{name: "unknown sort", page: PageRequest{Sort: "id"}, wantErr: true},
+		{name: "matching cursor", page: PageRequest{Sort: "serverName", Cursor: cursor}, wantSort: "serverName", wantLimit: DefaultPageSize},
+		{name: "cursor for another sort", page: PageRequest{Sort: "createdAt", Cursor: cursor}, wantErr: true},
+		{name: "garbage cursor", page: PageRequest{Cursor: "%%%"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		page := tt.page
+		err := page.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if page.Sort != tt.wantSort || page.Limit != tt.wantLimit {
+			t.Errorf("%s: Validate() sort, limit = %q, %d; want %q, %d", tt.name, page.Sort, page.Limit, tt.wantSort, tt.wantLimit)
+		}
+	}
+}
+
+func TestFindPageWalksEveryRow(t *testing.T) {
+	conn := dbtest.Open(t)
+	m := NewReservationModel(conn)
+	ctx := context.Background()
+
+	// Pairs of rows share a creation time so the ID has to break ties
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	for i := 1; i <= 9; i++ {
+		created := base.Add(time.Duration(i/2) * time.Minute)
+		status := StatusReserved
+		if i%3 == 0 {
+			status = StatusCommitted
+		}
+		err := m.Create(ctx, tx, &Reservation{
+			ID: uuid.New().String(), ServerName: fmt.Sprintf("ABCVAWEU1PWB%03d", 10-i),
+			UnitCode: "ABC", Type: "V", Provider: "A", Region: "WEU1", Environment: "P", Function: "WB",
+			SequenceNum: 10 - i, Status: status, CreatedAt: created, UpdatedAt: created,
+		})
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	for _, tc := range []struct {
+		filter ReservationFilter
+		page   PageRequest
+		total  int
+	}{
+		{page: PageRequest{Limit: 2}, total: 9},
+		{page: PageRequest{Limit: 4, Descending: true}, total: 9},
+		{page: PageRequest{Sort: "serverName", Limit: 3}, total: 9},
+		{page: PageRequest{Sort: "sequenceNum", Limit: 5, Descending: true}, total: 9},
+		{filter: ReservationFilter{Statuses: []string{StatusCommitted}}, page: PageRequest{Sort: "updatedAt", Limit: 2}, total: 3},
+	} {
+		all, err := m.Find(ctx, tc.filter)
+		if err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		want := make([]*Reservation, len(all))
+		copy(want, all)
+		s := reservationSorts[tc.page.Sort]
+		if tc.page.Sort == "" {
+			s = reservationSorts["createdAt"]
+		}
+		sort.Slice(want, func(i, j int) bool {
+			// Every sort value in this data set compares correctly as a string
+			a, b := s.value(want[i]), s.value(want[j])
+			if a == b {
+				a, b = want[i].ID, want[j].ID
+			}
+			return (a < b) != tc.page.Descending
+		})
+
+		var got []*Reservation
+		page := tc.page
+		for pages := 0; ; pages++ {
+			if pages > len(want) {
+				t.Fatalf("FindPage(%+v) does not terminate", tc.page)
+			}
+			if err := page.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			result, err := m.FindPage(ctx, tc.filter, page)
+			if err != nil {
+				t.Fatalf("FindPage() error = %v", err)
+			}
+			if result.Total != tc.total {
+				t.Errorf("FindPage(%+v) total = %d, want %d", tc.page, result.Total, tc.total)
+			}
+			got = append(got, result.Reservations...)
+			if result.NextCursor == "" {
+				break
+			}
+			page.Cursor = result.NextCursor
+		}
+
+		if len(got) != len(want) {
+			t.Fatalf("FindPage(%+v) returned %d rows over all pages, want %d", tc.page, len(got), len(want))
+		}
+		for i := range want {
+			if got[i].ID != want[i].ID {
+				t.Errorf("FindPage(%+v) row %d = %s, want %s", tc.page, i, got[i].ServerName, want[i].ServerName)
+			}
+		}
+	}
+}
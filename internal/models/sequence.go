@@ -5,16 +5,64 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 )
 
+// Overflow policies applied when a sequence runs past the numeric range of a scheme
+const (
+	OverflowReject   = "reject"   // Fail with a prefix exhausted error
+	OverflowReuse    = "reuse"    // Reuse the lowest number no longer held by a reservation
+	OverflowAlphabet = "alphabet" // Continue with letter-led alphanumeric sequences
+)
+
+// Allocation modes for picking the next sequence number
+const (
+	AllocationIncrement = "increment" // Always take the counter's next value
+	AllocationGapFill   = "gap_fill"  // Take the lowest number not held by a reservation
+)
+
+// SequenceSettings holds the per-key allocation settings
+type SequenceSettings struct {
+	OverflowPolicy       string `json:"overflowPolicy"`
+	AllocationMode       string `json:"allocationMode"`
+	ReuseCooldownSeconds int    `json:"reuseCooldownSeconds"`
+}
+
+// DefaultSequenceSettings are used for keys without a stored counter
+var DefaultSequenceSettings = SequenceSettings{
+	OverflowPolicy: OverflowReject,
+	AllocationMode: AllocationIncrement,
+}
+
 // SequenceKey defines a unique key to identify a naming sequence
 type SequenceKey struct {
-	UnitCode    string
-	Type        string
-	Provider    string
-	Region      string
-	Environment string
-	Function    string
+	UnitCode    string `json:"unitCode"`
+	Type        string `json:"type"`
+	Provider    string `json:"provider"`
+	Region      string `json:"region"`
+	Environment string `json:"environment"`
+	Function    string `json:"function"`
+}
+
+// SequenceKeyFor returns the sequence key for a normalized reservation payload
+func SequenceKeyFor(params ReservationPayload) SequenceKey {
+	return SequenceKey{
+		UnitCode:    params.UnitCode,
+		Type:        params.Type,
+		Provider:    params.Provider,
+		Region:      params.Region,
+		Environment: params.Environment,
+		Function:    params.Function,
+	}
+}
+
+// Sequence is the stored counter for a naming sequence
+type Sequence struct {
+	SequenceKey
+	SequenceSettings
+	CurrentValue int       `json:"currentValue"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
 // SequenceModel handles database operations for naming sequences
@@ -36,7 +84,7 @@ func (m *SequenceModel) GetNextSequenceNumber(ctx context.Context, tx *sql.Tx, k
 			$1, $2, $3, $4, $5, $6, 1
 		) 
 		ON CONFLICT (unit_code, type, provider, region, environment, function) 
-		DO UPDATE SET current_value = sequences.current_value + 1
+		DO UPDATE SET current_value = sequences.current_value + 1, updated_at = NOW()
 		RETURNING current_value
 	`
 
@@ -93,3 +141,217 @@ func (m *SequenceModel) GetCurrentSequenceNumber(ctx context.Context, key Sequen
 
 	return sequenceNum, nil
 }
+
+// SetSequenceNumber creates or overwrites the counter for a given key
+func (m *SequenceModel) SetSequenceNumber(ctx context.Context, tx *sql.Tx, key SequenceKey, value int) error {
+	query := `
+		INSERT INTO sequences (
+			unit_code, type, provider, region, environment, function, current_value
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+		ON CONFLICT (unit_code, type, provider, region, environment, function)
+		DO UPDATE SET current_value = EXCLUDED.current_value, updated_at = NOW()
+	`
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		key.UnitCode,
+		key.Type,
+		key.Provider,
+		key.Region,
+		key.Environment,
+		key.Function,
+		value,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set sequence number: %w", err)
+	}
+
+	return nil
+}
+
+// GetSettings retrieves the allocation settings for a key, falling back to
+// DefaultSequenceSettings when the key has no counter yet
+func (m *SequenceModel) GetSettings(ctx context.Context, tx *sql.Tx, key SequenceKey) (SequenceSettings, error) {
+	query := `
+		SELECT overflow_policy, allocation_mode, reuse_cooldown_seconds
+		FROM sequences
+		WHERE unit_code = $1
+		  AND type = $2
+		  AND provider = $3
+		  AND region = $4
+		  AND environment = $5
+		  AND function = $6
+	`
+
+	var settings SequenceSettings
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		key.UnitCode,
+		key.Type,
+		key.Provider,
+		key.Region,
+		key.Environment,
+		key.Function,
+	).Scan(&settings.OverflowPolicy, &settings.AllocationMode, &settings.ReuseCooldownSeconds)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return DefaultSequenceSettings, nil
+		}
+		return settings, fmt.Errorf("failed to get sequence settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// UpdateSettings changes the allocation settings for a key, creating the
+// counter if needed. Nil values leave the stored setting unchanged.
+func (m *SequenceModel) UpdateSettings(ctx context.Context, key SequenceKey, overflowPolicy, allocationMode *string, reuseCooldownSeconds *int) error {
+	query := `
+		INSERT INTO sequences (
+			unit_code, type, provider, region, environment, function, current_value,
+			overflow_policy, allocation_mode, reuse_cooldown_seconds
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, 0,
+			COALESCE($7::varchar, $10), COALESCE($8::varchar, $11), COALESCE($9::int, 0)
+		)
+		ON CONFLICT (unit_code, type, provider, region, environment, function)
+		DO UPDATE SET
+			overflow_policy = COALESCE($7::varchar, sequences.overflow_policy),
+			allocation_mode = COALESCE($8::varchar, sequences.allocation_mode),
+			reuse_cooldown_seconds = COALESCE($9::int, sequences.reuse_cooldown_seconds),
+			updated_at = NOW()
+	`
+
+	_, err := m.DB.ExecContext(
+		ctx,
+		query,
+		key.UnitCode,
+		key.Type,
+		key.Provider,
+		key.Region,
+		key.Environment,
+		key.Function,
+		overflowPolicy,
+		allocationMode,
+		reuseCooldownSeconds,
+		DefaultSequenceSettings.OverflowPolicy,
+		DefaultSequenceSettings.AllocationMode,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update sequence settings: %w", err)
+	}
+
+	return nil
+}
+
+// AdvanceSequenceNumber raises the counter for a key to at least value
+func (m *SequenceModel) AdvanceSequenceNumber(ctx context.Context, tx *sql.Tx, key SequenceKey, value int) error {
+	query := `
+		INSERT INTO sequences (
+			unit_code, type, provider, region, environment, function, current_value
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+		ON CONFLICT (unit_code, type, provider, region, environment, function)
+		DO UPDATE SET current_value = GREATEST(sequences.current_value, EXCLUDED.current_value), updated_at = NOW()
+	`
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		key.UnitCode,
+		key.Type,
+		key.Provider,
+		key.Region,
+		key.Environment,
+		key.Function,
+		value,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to advance sequence number: %w", err)
+	}
+
+	return nil
+}
+
+// RecordTombstone remembers when a deleted sequence number was last committed,
+// so gap-filling allocation can hold it back for a cool-down period
+func (m *SequenceModel) RecordTombstone(ctx context.Context, tx *sql.Tx, key SequenceKey, sequenceNum int, lastCommittedAt time.Time) error {
+	query := `
+		INSERT INTO sequence_tombstones (
+			unit_code, type, provider, region, environment, function, sequence_num, last_committed_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)
+		ON CONFLICT (unit_code, type, provider, region, environment, function, sequence_num)
+		DO UPDATE SET last_committed_at = GREATEST(sequence_tombstones.last_committed_at, EXCLUDED.last_committed_at)
+	`
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		key.UnitCode,
+		key.Type,
+		key.Provider,
+		key.Region,
+		key.Environment,
+		key.Function,
+		sequenceNum,
+		lastCommittedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record sequence tombstone: %w", err)
+	}
+
+	return nil
+}
+
+// GetAll retrieves every sequence counter
+func (m *SequenceModel) GetAll(ctx context.Context) ([]*Sequence, error) {
+	query := `
+		SELECT unit_code, type, provider, region, environment, function,
+			   current_value, overflow_policy, allocation_mode, reuse_cooldown_seconds,
+			   created_at, updated_at
+		FROM sequences
+		ORDER BY unit_code, type, provider, region, environment, function
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sequences: %w", err)
+	}
+	defer rows.Close()
+
+	sequences := []*Sequence{}
+	for rows.Next() {
+		seq := &Sequence{}
+		err := rows.Scan(
+			&seq.UnitCode,
+			&seq.Type,
+			&seq.Provider,
+			&seq.Region,
+			&seq.Environment,
+			&seq.Function,
+			&seq.CurrentValue,
+			&seq.OverflowPolicy,
+			&seq.AllocationMode,
+			&seq.ReuseCooldownSeconds,
+			&seq.CreatedAt,
+			&seq.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sequence: %w", err)
+		}
+		sequences = append(sequences, seq)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sequences: %w", err)
+	}
+
+	return sequences, nil
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Limits on reservation tags
+const (
+	MaxTags           = 50
+	MaxTagValueLength = 256
+)
+
+// tagKeyPattern restricts tag keys to a conservative character set
+var tagKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]{0,62}$`)
+
+// Tag selector operators
+const (
+	TagOpEquals    = "="
+	TagOpNotEquals = "!="
+	TagOpExists    = "exists"
+	TagOpNotExists = "!exists"
+)
+
+// TagSelector matches reservations by one tag
+type TagSelector struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+// ValidateTagKey checks that a tag key is well formed
+func ValidateTagKey(key string) error {
+	if !tagKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid tag key %q", key)
+	}
+	return nil
+}
+
+// ValidateTags checks tag keys, value lengths and the number of tags
+func ValidateTags(tags map[string]string) error {
+	if len(tags) > MaxTags {
+		return fmt.Errorf("at most %d tags are allowed", MaxTags)
+	}
+	for key, value := range tags {
+		if err := ValidateTagKey(key); err != nil {
+			return err
+		}
+		if len(value) > MaxTagValueLength {
+			return fmt.Errorf("tag %s must be at most %d characters long", key, MaxTagValueLength)
+		}
+	}
+	return nil
+}
+
+// ParseTagSelectors parses a comma separated selector such as
+// "app=billing,env!=dev,team,!legacy". A bare key requires the tag to be
+// present and a key prefixed with ! requires it to be absent.
+func ParseTagSelectors(selector string) ([]TagSelector, error) {
+	var selectors []TagSelector
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var sel TagSelector
+		switch {
+		case strings.Contains(term, "!="):
+			parts := strings.SplitN(term, "!=", 2)
+			sel = TagSelector{Key: parts[0], Op: TagOpNotEquals, Value: parts[1]}
+		case strings.Contains(term, "="):
+			parts := strings.SplitN(term, "=", 2)
+			sel = TagSelector{Key: parts[0], Op: TagOpEquals, Value: parts[1]}
+		case strings.HasPrefix(term, "!"):
+			sel = TagSelector{Key: term[1:], Op: TagOpNotExists}
+		default:
+			sel = TagSelector{Key: term, Op: TagOpExists}
+		}
+
+		sel.Key = strings.TrimSpace(sel.Key)
+		sel.Value = strings.TrimSpace(sel.Value)
+		if err := ValidateTagKey(sel.Key); err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+	}
+
+	if len(selectors) == 0 {
+		return nil, errors.New("tag selector is empty")
+	}
+	return selectors, nil
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/db/dbtest"
+	"github.com/google/uuid"
+)
+
+func TestParseTagSelectors(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     []TagSelector
+		wantErr  bool
+	}{
+		{
+			selector: "app=billing",
+			want:     []TagSelector{{Key: "app", Op: TagOpEquals, Value: "billing"}},
+		},
+		{
+			selector: "app=billing,env!=dev,team,!legacy",
+			want: []TagSelector{
+				{Key: "app", Op: TagOpEquals, Value: "billing"},
+				{Key: "env", Op: TagOpNotEquals, Value: "dev"},
+				{Key: "team", Op: TagOpExists},
+				{Key: "legacy", Op: TagOpNotExists},
+			},
+		},
+		{
+			selector: " app = billing , , env != dev ",
+			want: []TagSelector{
+				{Key: "app", Op: TagOpEquals, Value: "billing"},
+				{Key: "env", Op: TagOpNotEquals, Value: "dev"},
+			},
+		},
+		{
+			selector: "app=",
+			want:     []TagSelector{{Key: "app", Op: TagOpEquals}},
+		},
+		{
+			selector: "url=a=b",
+			want:     []TagSelector{{Key: "url", Op: TagOpEquals, Value: "a=b"}},
+		},
+		{
+			selector: "cost.center/id=CC-1",
+			want:     []TagSelector{{Key: "cost.center/id", Op: TagOpEquals, Value: "CC-1"}},
+		},
+		{selector: "", wantErr: true},
+		{selector: " , ", wantErr: true},
+		{selector: "=billing", wantErr: true},
+		{selector: "!=dev", wantErr: true},
+		{selector: "!", wantErr: true},
+		{selector: "bad key=x", wantErr: true},
+		{selector: "-app=x", wantErr: true},
+		{selector: strings.Repeat("k", 64) + "=x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTagSelectors(tt.selector)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTagSelectors(%q) error = %v, wantErr %v", tt.selector, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTagSelectors(%q) = %+v, want %+v", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := 0; i <= MaxTags; i++ {
+		tooMany[fmt.Sprintf("tag%d", i)] = "v"
+	}
+
+	tests := []struct {
+		name    string
+		tags    map[string]string
+		wantErr bool
+	}{
+		{name: "nil", tags: nil},
+		{name: "valid", tags: map[string]string{"app": "billing", "cost.center": ""}},
+		{name: "longest value", tags: map[string]string{"app": strings.Repeat("v", MaxTagValueLength)}},
+		{name: "value too long", tags: map[string]string{"app": strings.Repeat("v", MaxTagValueLength+1)}, wantErr: true},
+		{name: "invalid key", tags: map[string]string{"a b": "x"}, wantErr: true},
+		{name: "too many", tags: tooMany, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateTags(tt.tags); (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateTags() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFindByTagSelectors(t *testing.T) {
+	conn := dbtest.Open(t)
+	m := NewReservationModel(conn)
+	ctx := context.Background()
+
+	tagged := map[string]map[string]string{
+		"BILLPROD001": {"app": "billing", "env": "prod", "team": "payments"},
+		"BILLDEV0001": {"app": "billing", "env": "dev"},
+		"WEBPROD0001": {"app": "web", "env": "prod", "legacy": ""},
+		"UNTAGGED001": nil,
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	now := time.Now().UTC()
+	seq := 0
+	for name, tags := range tagged {
+		seq++
+		err := m.Create(ctx, tx, &Reservation{
+			ID: uuid.New().String(), ServerName: name, UnitCode: "ABC", Type: "V", Provider: "A",
+			Region: "WEU1", Environment: "P", Function: "WB", SequenceNum: seq,
+			Status: StatusReserved, CreatedAt: now, UpdatedAt: now, Tags: tags,
+		})
+		if err != nil {
+			t.Fatalf("Create(%s) error = %v", name, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	for selector, want := range map[string][]string{
+		"app=billing":           {"BILLDEV0001", "BILLPROD001"},
+		"app=billing,env=prod":  {"BILLPROD001"},
+		"env!=prod":             {"BILLDEV0001", "UNTAGGED001"},
+		"team":                  {"BILLPROD001"},
+		"!team":                 {"BILLDEV0001", "UNTAGGED001", "WEBPROD0001"},
+		"legacy=":               {"WEBPROD0001"},
+		"app=billing,!team,env": {"BILLDEV0001"},
+		"app=unknown":           nil,
+	} {
+		selectors, err := ParseTagSelectors(selector)
+		if err != nil {
+			t.Fatalf("ParseTagSelectors(%q) error = %v", selector, err)
+		}
+		found, err := m.Find(ctx, ReservationFilter{Tags: selectors})
+		if err != nil {
+			t.Fatalf("Find(%q) error = %v", selector, err)
+		}
+
+		var got []string
+		for _, r := range found {
+			got = append(got, r.ServerName)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Find(%q) = %v, want %v", selector, got, want)
+		}
+	}
+}
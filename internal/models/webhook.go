@@ -0,0 +1,483 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // Ran out of attempts; kept as a dead letter
+)
+
+// EventPing is sent by the webhook ping endpoint to test a subscription
+const EventPing = "ping"
+
+// EventTypes lists every reservation event type a webhook can subscribe to
+var EventTypes = []string{
+	EventCreated,
+	EventCommitted,
+	EventReleased,
+	EventExpired,
+	EventDecommissioned,
+	EventRecommissioned,
+	EventRetired,
+	EventDeleted,
+	EventReclaimed,
+	EventTagsUpdated,
+}
+
+// IsEventType reports whether t is a known reservation event type
+func IsEventType(t string) bool {
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscription is an endpoint that receives reservation events. An
+// empty EventTypes list subscribes to every event.
+type WebhookSubscription struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"` // Only returned when the subscription is created
+	EventTypes  []string  `json:"eventTypes"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedBy   string    `json:"createdBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// WebhookPayload is the request payload for creating or updating a webhook
+// subscription. A secret is generated when none is given.
+type WebhookPayload struct {
+	URL         string   `json:"url" validate:"required,url,max=2000"`
+	Secret      string   `json:"secret,omitempty" validate:"omitempty,min=16,max=200"`
+	EventTypes  []string `json:"eventTypes,omitempty"`
+	Description string   `json:"description,omitempty" validate:"omitempty,max=500"`
+	Active      *bool    `json:"active,omitempty"`
+}
+
+// GenerateWebhookSecret returns a random secret for signing webhook payloads
+func GenerateWebhookSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate webhook secret: %v", err))
+	}
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// WebhookDelivery is one queued delivery of an event to a subscription
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID string          `json:"subscriptionId"`
+	EventID        int64           `json:"eventId,omitempty"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"`
+	LastStatusCode int             `json:"lastStatusCode,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+
+	// Target of the delivery, filled in when deliveries are claimed
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookModel handles database operations for webhook subscriptions and
+// their delivery queue
+type WebhookModel struct {
+	DB *sql.DB
+}
+
+// NewWebhookModel creates a new webhook model
+func NewWebhookModel(db *sql.DB) *WebhookModel {
+	return &WebhookModel{DB: db}
+}
+
+const webhookColumns = `
+	id, url, secret, event_types, description, active, created_by, created_at, updated_at
+`
+
+// scanWebhook scans a single subscription row selected with webhookColumns
+func scanWebhook(row interface{ Scan(...any) error }) (*WebhookSubscription, error) {
+	var description, createdBy sql.NullString
+	w := &WebhookSubscription{}
+	err := row.Scan(
+		&w.ID,
+		&w.URL,
+		&w.Secret,
+		pq.Array(&w.EventTypes),
+		&description,
+		&w.Active,
+		&createdBy,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Description = description.String
+	w.CreatedBy = createdBy.String
+	if w.EventTypes == nil {
+		w.EventTypes = []string{}
+	}
+	return w, nil
+}
+
+const deliveryColumns = `
+	d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at
+`
+
+// scanDelivery scans a single delivery row selected with deliveryColumns
+func scanDelivery(row interface{ Scan(...any) error }, extra ...any) (*WebhookDelivery, error) {
+	var eventID sql.NullInt64
+	var statusCode sql.NullInt64
+	var lastError sql.NullString
+	var payload []byte
+	d := &WebhookDelivery{}
+	dest := []any{
+		&d.ID,
+		&d.SubscriptionID,
+		&eventID,
+		&d.EventType,
+		&payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&statusCode,
+		&lastError,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	d.EventID = eventID.Int64
+	d.LastStatusCode = int(statusCode.Int64)
+	d.LastError = lastError.String
+	d.Payload = json.RawMessage(payload)
+	return d, nil
+}
+
+// Create inserts a new subscription
+func (m *WebhookModel) Create(ctx context.Context, w *WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (
+			id, url, secret, event_types, description, active, created_by, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)
+	`
+
+	_, err := m.DB.ExecContext(
+		ctx,
+		query,
+		w.ID,
+		w.URL,
+		w.Secret,
+		pq.Array(w.EventTypes),
+		nullIfEmpty(w.Description),
+		w.Active,
+		nullIfEmpty(w.CreatedBy),
+		w.CreatedAt,
+		w.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a subscription by ID
+func (m *WebhookModel) GetByID(ctx context.Context, id string) (*WebhookSubscription, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	w, err := scanWebhook(m.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return w, nil
+}
+
+// GetAll retrieves every subscription, oldest first
+func (m *WebhookModel) GetAll(ctx context.Context) ([]*WebhookSubscription, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhook_subscriptions ORDER BY created_at`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []*WebhookSubscription{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// Update saves the URL, secret, event types, description and active flag of
+// a subscription. It reports false when the subscription does not exist.
+func (m *WebhookModel) Update(ctx context.Context, w *WebhookSubscription) (bool, error) {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $1, secret = $2, event_types = $3, description = $4, active = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	result, err := m.DB.ExecContext(
+		ctx,
+		query,
+		w.URL,
+		w.Secret,
+		pq.Array(w.EventTypes),
+		nullIfEmpty(w.Description),
+		w.Active,
+		w.UpdatedAt,
+		w.ID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Delete removes a subscription and its queued deliveries. It reports false
+// when the subscription does not exist.
+func (m *WebhookModel) Delete(ctx context.Context, id string) (bool, error) {
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Enqueue queues an event for every active subscription that wants it. It
+// runs inside the transaction that records the event, so a delivery is
+// queued if and only if the change is committed.
+func (m *WebhookModel) Enqueue(ctx context.Context, tx *sql.Tx, e *ReservationEvent) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries (
+			subscription_id, event_id, event_type, payload, next_attempt_at, created_at
+		)
+		SELECT id, $1, $2, $3, $4, $4
+		FROM webhook_subscriptions
+		WHERE active AND (cardinality(event_types) = 0 OR $2::text = ANY(event_types))
+	`
+
+	if _, err := tx.ExecContext(ctx, query, e.ID, e.Type, payload, e.CreatedAt); err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// EnqueuePing queues a ping delivery for a single subscription
+func (m *WebhookModel) EnqueuePing(ctx context.Context, subscriptionID string, payload []byte, now time.Time) (*WebhookDelivery, error) {
+	query := `
+		INSERT INTO webhook_deliveries AS d (
+			subscription_id, event_type, payload, next_attempt_at, created_at
+		) VALUES (
+			$1, $2, $3, $4, $4
+		)
+		RETURNING ` + deliveryColumns
+
+	d, err := scanDelivery(m.DB.QueryRowContext(ctx, query, subscriptionID, EventPing, payload, now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue webhook ping: %w", err)
+	}
+
+	return d, nil
+}
+
+// ClaimDue leases up to limit pending deliveries that are due, oldest first.
+// Claimed deliveries are counted as attempted and hidden from other workers
+// until lease has passed, so a worker that dies mid-delivery only delays them.
+// Deliveries of inactive subscriptions wait until the subscription is enabled.
+func (m *WebhookModel) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries AS d
+		SET attempts = d.attempts + 1, next_attempt_at = $2
+		FROM (
+			SELECT pending.id FROM webhook_deliveries AS pending
+			JOIN webhook_subscriptions AS sub ON sub.id = pending.subscription_id
+			WHERE pending.status = $3 AND pending.next_attempt_at <= $1 AND sub.active
+			ORDER BY pending.next_attempt_at, pending.id
+			LIMIT $4
+			FOR UPDATE OF pending SKIP LOCKED
+		) AS due, webhook_subscriptions AS s
+		WHERE d.id = due.id AND s.id = d.subscription_id
+		RETURNING ` + deliveryColumns + `, s.url, s.secret`
+
+	rows, err := m.DB.QueryContext(ctx, query, now, now.Add(lease), DeliveryPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		var url, secret string
+		d, err := scanDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		d.URL, d.Secret = url, secret
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// MarkDelivered records a successful delivery
+func (m *WebhookModel) MarkDelivered(ctx context.Context, id int64, statusCode int, now time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, last_status_code = $2, last_error = NULL, delivered_at = $3
+		WHERE id = $4
+	`
+
+	if _, err := m.DB.ExecContext(ctx, query, DeliveryDelivered, statusCode, now, id); err != nil {
+		return fmt.Errorf("failed to mark webhook delivered: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed attempt. The delivery is retried at
+// nextAttemptAt, or moved to the dead-letter list when dead is true.
+func (m *WebhookModel) MarkFailed(ctx context.Context, id int64, statusCode int, lastError string, nextAttemptAt time.Time, dead bool) error {
+	status := DeliveryPending
+	if dead {
+		status = DeliveryDead
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, last_status_code = $2, last_error = $3, next_attempt_at = $4
+		WHERE id = $5
+	`
+
+	var code any
+	if statusCode > 0 {
+		code = statusCode
+	}
+	if _, err := m.DB.ExecContext(ctx, query, status, code, lastError, nextAttemptAt, id); err != nil {
+		return fmt.Errorf("failed to mark webhook failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteDelivered removes deliveries delivered before a cut-off and returns
+// how many were removed. Pending deliveries and dead letters are kept.
+func (m *WebhookModel) DeleteDelivered(ctx context.Context, before time.Time) (int64, error) {
+	result, err := m.DB.ExecContext(ctx,
+		`DELETE FROM webhook_deliveries WHERE status = $1 AND delivered_at < $2`, DeliveryDelivered, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetDeadLetters retrieves deliveries that ran out of attempts, newest first.
+// An empty subscriptionID matches every subscription.
+func (m *WebhookModel) GetDeadLetters(ctx context.Context, subscriptionID string, limit int) ([]*WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries AS d
+		WHERE d.status = $1 AND ($2 = '' OR d.subscription_id::text = $2)
+		ORDER BY d.id DESC
+		LIMIT $3`
+
+	rows, err := m.DB.QueryContext(ctx, query, DeliveryDead, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dead letters: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver moves a dead letter back to the queue with a fresh set of
+// attempts. It reports false when id is not a dead letter.
+func (m *WebhookModel) Redeliver(ctx context.Context, id int64, now time.Time) (bool, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = 0, next_attempt_at = $2
+		WHERE id = $3 AND status = $4
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, DeliveryPending, now, id, DeliveryDead)
+	if err != nil {
+		return false, fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+	return n > 0, nil
+}
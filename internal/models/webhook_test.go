@@ -0,0 +1,156 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/db/dbtest"
+	"github.com/google/uuid"
+)
+
+func TestWebhookQueue(t *testing.T) {
+	m := NewWebhookModel(dbtest.Open(t))
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	sub := &WebhookSubscription{
+		ID:        uuid.New().String(),
+		URL:       "https://hooks.example.com/names",
+		Secret:    GenerateWebhookSecret(),
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := m.Create(ctx, sub); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	ping, err := m.EnqueuePing(ctx, sub.ID, []byte(`{"type":"ping"}`), now)
+	if err != nil {
+		t.Fatalf("EnqueuePing() error = %v", err)
+	}
+
+	claim := func(at time.Time) []*WebhookDelivery {
+		t.Helper()
+		claimed, err := m.ClaimDue(ctx, at, time.Minute, 10)
+		if err != nil {
+			t.Fatalf("ClaimDue() error = %v", err)
+		}
+		return claimed
+	}
+
+	claimed := claim(now)
+	if len(claimed) != 1 || claimed[0].ID != ping.ID {
+		t.Fatalf("ClaimDue() = %v, want the ping", claimed)
+	}
+	if d := claimed[0]; d.Attempts != 1 || d.URL != sub.URL || d.Secret != sub.Secret {
+		t.Errorf("claimed delivery attempts, url, secret = %d, %q, %q; want 1 and the subscription's target",
+			d.Attempts, d.URL, d.Secret)
+	}
+
+	// The lease hides the delivery from other workers until it runs out
+	if claimed := claim(now.Add(30 * time.Second)); len(claimed) != 0 {
+		t.Errorf("ClaimDue() during the lease = %d deliveries, want none", len(claimed))
+	}
+	claimed = claim(now.Add(2 * time.Minute))
+	if len(claimed) != 1 || claimed[0].Attempts != 2 {
+		t.Fatalf("ClaimDue() after the lease = %v, want the ping on its second attempt", claimed)
+	}
+
+	if err := m.MarkFailed(ctx, ping.ID, 500, "unexpected status 500", now.Add(time.Hour), true); err != nil {
+		t.Fatalf("MarkFailed() error = %v", err)
+	}
+	if claimed := claim(now.Add(2 * time.Hour)); len(claimed) != 0 {
+		t.Errorf("ClaimDue() returned %d dead deliveries", len(claimed))
+	}
+
+	dead, err := m.GetDeadLetters(ctx, sub.ID, 10)
+	if err != nil {
+		t.Fatalf("GetDeadLetters() error = %v", err)
+	}
+	if len(dead) != 1 || dead[0].LastStatusCode != 500 || dead[0].LastError != "unexpected status 500" {
+		t.Fatalf("GetDeadLetters() = %+v, want the failed ping", dead)
+	}
+
+	if ok, err := m.Redeliver(ctx, ping.ID, now.Add(3*time.Hour)); err != nil || !ok {
+		t.Fatalf("Redeliver() = %v, %v; want true", ok, err)
+	}
+	if ok, err := m.Redeliver(ctx, ping.ID, now.Add(3*time.Hour)); err != nil || ok {
+		t.Errorf("Redeliver() of a pending delivery = %v, %v; want false", ok, err)
+	}
+	claimed = claim(now.Add(3 * time.Hour))
+	if len(claimed) != 1 || claimed[0].Attempts != 1 {
+		t.Fatalf("ClaimDue() after Redeliver() = %v, want a fresh first attempt", claimed)
+	}
+}
+
+func TestDeleteDelivered(t *testing.T) {
+	m := NewWebhookModel(dbtest.Open(t))
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	sub := &WebhookSubscription{ID: uuid.New().String(), URL: "https://hooks.example.com", Secret: "s", Active: true, CreatedAt: now, UpdatedAt: now}
+	if err := m.Create(ctx, sub); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		d, err := m.EnqueuePing(ctx, sub.ID, []byte(`{}`), now)
+		if err != nil {
+			t.Fatalf("EnqueuePing() error = %v", err)
+		}
+		ids = append(ids, d.ID)
+	}
+	// An old delivery, a recent one, and one still pending
+	if err := m.MarkDelivered(ctx, ids[0], 200, now.Add(-48*time.Hour)); err != nil {
+		t.Fatalf("MarkDelivered() error = %v", err)
+	}
+	if err := m.MarkDelivered(ctx, ids[1], 200, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("MarkDelivered() error = %v", err)
+	}
+
+	n, err := m.DeleteDelivered(ctx, now.Add(-24*time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("DeleteDelivered() = %d, %v; want 1", n, err)
+	}
+	claimed, err := m.ClaimDue(ctx, now, time.Minute, 10)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != ids[2] {
+		t.Errorf("ClaimDue() after purge = %v, %v; want only the pending delivery", claimed, err)
+	}
+}
+
+func TestClaimDueSkipsInactiveSubscriptions(t *testing.T) {
+	m := NewWebhookModel(dbtest.Open(t))
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	sub := &WebhookSubscription{ID: uuid.New().String(), URL: "https://hooks.example.com", Secret: "s", Active: true, CreatedAt: now, UpdatedAt: now}
+	if err := m.Create(ctx, sub); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	ping, err := m.EnqueuePing(ctx, sub.ID, []byte(`{}`), now)
+	if err != nil {
+		t.Fatalf("EnqueuePing() error = %v", err)
+	}
+
+	setActive := func(active bool) {
+		t.Helper()
+		sub.Active = active
+		if ok, err := m.Update(ctx, sub); err != nil || !ok {
+			t.Fatalf("Update() = %v, %v; want true", ok, err)
+		}
+	}
+
+	setActive(false)
+	if claimed, err := m.ClaimDue(ctx, now, time.Minute, 10); err != nil || len(claimed) != 0 {
+		t.Errorf("ClaimDue() for a disabled subscription = %v, %v; want none", claimed, err)
+	}
+
+	// The delivery keeps its place and goes out once the subscription is enabled
+	setActive(true)
+	claimed, err := m.ClaimDue(ctx, now, time.Minute, 10)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != ping.ID || claimed[0].Attempts != 1 {
+		t.Errorf("ClaimDue() after enabling = %v, %v; want the ping on its first attempt", claimed, err)
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
+)
+
+// ParseServerName reverses GenerateServerName for a scheme. Schemes with a
+// separator accept segments shorter than their width; schemes without one can
+// only be decoded when every segment uses its full width. It reports false
+// when the name does not fit the scheme.
+func (s *NameGeneratorService) ParseServerName(scheme *models.NamingScheme, name string) (models.ReservationPayload, int, bool) {
+	var params models.ReservationPayload
+	name = strings.ToUpper(name)
+
+	var parts []string
+	if scheme.Separator != "" {
+		parts = strings.Split(name, strings.ToUpper(scheme.Separator))
+		if len(parts) != len(scheme.Segments)+1 {
+			return params, 0, false
+		}
+	} else {
+		expected := scheme.SequenceWidth
+		for _, seg := range scheme.Segments {
+			expected += seg.Length
+		}
+		if len(name) != expected {
+			return params, 0, false
+		}
+
+		offset := 0
+		for _, seg := range scheme.Segments {
+			parts = append(parts, name[offset:offset+seg.Length])
+			offset += seg.Length
+		}
+		parts = append(parts, name[offset:])
+	}
+
+	for i, seg := range scheme.Segments {
+		value := parts[i]
+		if value == "" || len(value) > seg.Length || !matchesCharset(value, seg.Charset) {
+			return params, 0, false
+		}
+		params.SetSegmentValue(seg.Field, value)
+	}
+
+	sequenceNum, ok := ParseSequence(parts[len(parts)-1], scheme.SequenceWidth)
+	if !ok {
+		return params, 0, false
+	}
+
+	return params, sequenceNum, true
+}
+
+// DecodeServerName splits a server name back into its segments. A matching
+// reservation record is preferred because it names the scheme the name was
+// generated with; otherwise the active schemes are tried, default first.
+func (s *NameGeneratorService) DecodeServerName(ctx context.Context, name string) (_ *models.DecodedName, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.DecodeServerName")
+	defer func() { tracing.End(span, err) }()
+
+	reservation, err := s.reservationModel.GetByServerName(ctx, name)
+	if err != nil {
+		return nil, errors.NewDatabaseError("Failed to look up reservation", err)
+	}
+
+	var scheme *models.NamingScheme
+	var params models.ReservationPayload
+	var sequenceNum int
+
+	if reservation != nil {
+		scheme, err = s.reservationScheme(ctx, reservation)
+		if err != nil {
+			return nil, err
+		}
+		params = models.ReservationPayload{
+			UnitCode:    reservation.UnitCode,
+			Type:        reservation.Type,
+			Provider:    reservation.Provider,
+			Region:      reservation.Region,
+			Environment: reservation.Environment,
+			Function:    reservation.Function,
+		}
+		sequenceNum = reservation.SequenceNum
+	} else {
+		candidates, err := s.decodeCandidates(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, candidate := range candidates {
+			if params, sequenceNum, found = s.ParseServerName(candidate, name); found {
+				scheme = candidate
+				break
+			}
+		}
+		if !found {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("Server name %s does not match any naming scheme", name))
+		}
+	}
+
+	decoded := &models.DecodedName{
+		ServerName:    name,
+		Scheme:        scheme.Name,
+		SchemeVersion: scheme.Version,
+		SequenceNum:   sequenceNum,
+		Reservation:   reservation,
+	}
+	if reservation != nil {
+		decoded.ServerName = reservation.ServerName
+	}
+
+	for _, seg := range scheme.Segments {
+		value := params.SegmentValue(seg.Field)
+		entry, err := s.catalogModel.Get(ctx, seg.Field, value)
+		if err != nil {
+			return nil, errors.NewDatabaseError("Failed to load segment catalog", err)
+		}
+
+		segment := models.DecodedSegment{Field: seg.Field, Value: value}
+		if entry != nil {
+			segment.Description = entry.Description
+		}
+		decoded.Segments = append(decoded.Segments, segment)
+	}
+
+	return decoded, nil
+}
+
+// reservationScheme returns the scheme a reservation was generated with.
+// Reservations made before naming schemes existed use the compact scheme.
+func (s *NameGeneratorService) reservationScheme(ctx context.Context, reservation *models.Reservation) (*models.NamingScheme, error) {
+	if reservation.SchemeID == "" {
+		return models.DefaultNamingScheme(), nil
+	}
+
+	scheme, err := s.schemeModel.GetByID(ctx, reservation.SchemeID)
+	if err != nil {
+		return nil, errors.NewDatabaseError("Failed to load naming scheme", err)
+	}
+	if scheme == nil {
+		return models.DefaultNamingScheme(), nil
+	}
+	return scheme, nil
+}
+
+// decodeCandidates returns the active naming schemes, default scheme first
+func (s *NameGeneratorService) decodeCandidates(ctx context.Context) ([]*models.NamingScheme, error) {
+	schemes, err := s.schemeModel.GetAll(ctx)
+	if err != nil {
+		return nil, errors.NewDatabaseError("Failed to load naming schemes", err)
+	}
+
+	var candidates []*models.NamingScheme
+	for _, scheme := range schemes {
+		if !scheme.IsActive {
+			continue
+		}
+		if scheme.IsDefault {
+			candidates = append([]*models.NamingScheme{scheme}, candidates...)
+		} else {
+			candidates = append(candidates, scheme)
+		}
+	}
+
+	if len(candidates) == 0 {
+		candidates = append(candidates, models.DefaultNamingScheme())
+	}
+	return candidates, nil
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+)
+
+// compactScheme is modeled on the seeded fixed-width scheme, with an
+// alpha-only environment segment
+var compactScheme = &models.NamingScheme{
+	Name: "compact",
+	Case: models.CaseUpper,
+	Segments: []models.SchemeSegment{
+		{Field: models.SegmentUnitCode, Length: 3, Charset: models.CharsetAlphanumeric},
+		{Field: models.SegmentType, Length: 1, Charset: models.CharsetAlphanumeric},
+		{Field: models.SegmentProvider, Length: 1, Charset: models.CharsetAlphanumeric},
+		{Field: models.SegmentRegion, Length: 4, Charset: models.CharsetAlphanumeric},
+		{Field: models.SegmentEnvironment, Length: 1, Charset: models.CharsetAlpha},
+		{Field: models.SegmentFunction, Length: 2, Charset: models.CharsetAlphanumeric},
+	},
+	SequenceWidth: 3,
+}
+
+// linuxScheme is modeled on the seeded dash-separated lowercase scheme
+var linuxScheme = &models.NamingScheme{
+	Name:      "linux",
+	Separator: "-",
+	Case:      models.CaseLower,
+	Segments: []models.SchemeSegment{
+		{Field: models.SegmentUnitCode, Length: 3, Charset: models.CharsetAlphanumeric},
+		{Field: models.SegmentRegion, Length: 4, Charset: models.CharsetAlphanumeric},
+		{Field: models.SegmentEnvironment, Length: 1, Charset: models.CharsetAlpha},
+		{Field: models.SegmentFunction, Length: 2, Charset: models.CharsetAlphanumeric},
+	},
+	SequenceWidth: 3,
+}
+
+func TestParseServerName(t *testing.T) {
+	s := &NameGeneratorService{}
+
+	tests := []struct {
+		name       string
+		scheme     *models.NamingScheme
+		serverName string
+		want       models.ReservationPayload
+		wantSeq    int
+		wantOK     bool
+	}{
+		{
+			name:       "compact",
+			scheme:     compactScheme,
+			serverName: "ABCVAWEU1PWB007",
+			want: models.ReservationPayload{
+				UnitCode: "ABC", Type: "V", Provider: "A", Region: "WEU1", Environment: "P", Function: "WB",
+			},
+			wantSeq: 7,
+			wantOK:  true,
+		},
+		{
+			name:       "compact lowercase input",
+			scheme:     compactScheme,
+			serverName: "abcvaweu1pwb007",
+			want: models.ReservationPayload{
+				UnitCode: "ABC", Type: "V", Provider: "A", Region: "WEU1", Environment: "P", Function: "WB",
+			},
+			wantSeq: 7,
+			wantOK:  true,
+		},
+		{
+			name:       "compact alphabet sequence",
+			scheme:     compactScheme,
+			serverName: "ABCVAWEU1PWBA00",
+			want: models.ReservationPayload{
+				UnitCode: "ABC", Type: "V", Provider: "A", Region: "WEU1", Environment: "P", Function: "WB",
+			},
+			wantSeq: 1000,
+			wantOK:  true,
+		},
+		{name: "compact too short", scheme: compactScheme, serverName: "ABCVAWEUPWB007"},
+		{name: "compact too long", scheme: compactScheme, serverName: "ABCVAWEU1PWB0007"},
+		{name: "compact charset mismatch", scheme: compactScheme, serverName: "ABCVAWEU11WB007"},
+		{name: "compact bad sequence", scheme: compactScheme, serverName: "ABCVAWEU1PWB0-7"},
+		{
+			name:       "separated",
+			scheme:     linuxScheme,
+			serverName: "abc-weu-p-wb-007",
+			want: models.ReservationPayload{
+				UnitCode: "ABC", Region: "WEU", Environment: "P", Function: "WB",
+			},
+			wantSeq: 7,
+			wantOK:  true,
+		},
+		{name: "separated missing segment", scheme: linuxScheme, serverName: "abc-weu-p-007"},
+		{name: "separated extra segment", scheme: linuxScheme, serverName: "abc-weu-p-wb-x-007"},
+		{name: "separated empty segment", scheme: linuxScheme, serverName: "abc--p-wb-007"},
+		{name: "separated segment too long", scheme: linuxScheme, serverName: "abcd-weu-p-wb-007"},
+		{name: "separated short sequence", scheme: linuxScheme, serverName: "abc-weu-p-wb-07"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, seq, ok := s.ParseServerName(tt.scheme, tt.serverName)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseServerName(%q) ok = %v, want %v", tt.serverName, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseServerName(%q) = %+v, want %+v", tt.serverName, got, tt.want)
+			}
+			if seq != tt.wantSeq {
+				t.Errorf("ParseServerName(%q) sequence = %d, want %d", tt.serverName, seq, tt.wantSeq)
+			}
+		})
+	}
+}
+
+func TestParseServerNameRoundTrip(t *testing.T) {
+	s := &NameGeneratorService{}
+	params := models.ReservationPayload{
+		UnitCode: "ABC", Type: "V", Provider: "A", Region: "WEU1", Environment: "P", Function: "WB",
+	}
+
+	for _, scheme := range []*models.NamingScheme{compactScheme, linuxScheme} {
+		for _, seq := range []int{1, 999, 1000, SequenceCapacity(scheme.SequenceWidth, models.OverflowAlphabet)} {
+			name := s.GenerateServerName(scheme, params, seq)
+			got, gotSeq, ok := s.ParseServerName(scheme, name)
+			if !ok || gotSeq != seq {
+				t.Errorf("%s: ParseServerName(%q) = %d, %v; want %d", scheme.Name, name, gotSeq, ok, seq)
+				continue
+			}
+			for _, seg := range scheme.Segments {
+				if got.SegmentValue(seg.Field) != params.SegmentValue(seg.Field) {
+					t.Errorf("%s: ParseServerName(%q) %s = %q, want %q", scheme.Name, name,
+						seg.Field, got.SegmentValue(seg.Field), params.SegmentValue(seg.Field))
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeServerName(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	web := &models.CatalogValue{
+		Segment: models.SegmentFunction, Code: "WB", Description: "Web server", CreatedAt: now, UpdatedAt: now,
+	}
+	if err := s.catalogModel.Create(ctx, web); err != nil {
+		t.Fatalf("Create() catalog value error = %v", err)
+	}
+
+	linux := webPayload
+	linux.Scheme = "linux"
+	reserved, err := s.ReserveServerName(ctx, linux)
+	if err != nil {
+		t.Fatalf("ReserveServerName() error = %v", err)
+	}
+
+	t.Run("reserved name", func(t *testing.T) {
+		decoded, err := s.DecodeServerName(ctx, reserved.ServerName)
+		if err != nil {
+			t.Fatalf("DecodeServerName() error = %v", err)
+		}
+		if decoded.Reservation == nil || decoded.Reservation.ID != reserved.ReservationID {
+			t.Fatalf("DecodeServerName() reservation = %+v, want %s", decoded.Reservation, reserved.ReservationID)
+		}
+		if decoded.Scheme != "linux" || decoded.SequenceNum != 1 {
+			t.Errorf("DecodeServerName() scheme, sequence = %s, %d; want linux, 1", decoded.Scheme, decoded.SequenceNum)
+		}
+	})
+
+	t.Run("unreserved name", func(t *testing.T) {
+		decoded, err := s.DecodeServerName(ctx, "ABCVAWEU1PWB042")
+		if err != nil {
+			t.Fatalf("DecodeServerName() error = %v", err)
+		}
+		if decoded.Reservation != nil {
+			t.Errorf("DecodeServerName() reservation = %+v, want none", decoded.Reservation)
+		}
+		if decoded.Scheme != "compact" || decoded.SequenceNum != 42 {
+			t.Errorf("DecodeServerName() scheme, sequence = %s, %d; want compact, 42", decoded.Scheme, decoded.SequenceNum)
+		}
+
+		descriptions := make(map[string]string)
+		for _, seg := range decoded.Segments {
+			descriptions[seg.Field] = seg.Description
+		}
+		if descriptions[models.SegmentFunction] != "Web server" {
+			t.Errorf("function description = %q, want catalog description", descriptions[models.SegmentFunction])
+		}
+	})
+
+	t.Run("no matching scheme", func(t *testing.T) {
+		_, err := s.DecodeServerName(ctx, "not-a-server")
+		if appErr, ok := err.(*errors.AppError); !ok || appErr.Type != errors.ErrorTypeNotFound {
+			t.Errorf("DecodeServerName() error = %v, want not found", err)
+		}
+	})
+}
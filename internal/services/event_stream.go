@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// Event broker limits
+const (
+	eventPollBatchSize = 500
+
+	// eventGapTimeout is how long a missing event ID is waited for before it
+	// is assumed to belong to a rolled back transaction
+	eventGapTimeout = 5 * time.Second
+
+	// EventReplayPageSize is how many stored events Replay returns at a time
+	EventReplayPageSize = 500
+
+	// MaxEventReplay is the most events replayed when a stream resumes.
+	// Clients further behind are told to reset instead.
+	MaxEventReplay = 10000
+)
+
+// EventFilter selects reservation events for a stream. Empty fields match
+// anything; segment and status filters are compared against the reservation
+// after the change, or before it for deletions.
+type EventFilter struct {
+	Types       []string
+	Statuses    []string
+	UnitCode    string
+	Type        string
+	Provider    string
+	Region      string
+	Environment string
+	Function    string
+}
+
+// Matches reports whether an event passes the filter
+func (f EventFilter) Matches(e *models.ReservationEvent) bool {
+	if len(f.Types) > 0 && !containsFold(f.Types, e.Type) {
+		return false
+	}
+
+	r := e.After
+	if r == nil {
+		r = e.Before
+	}
+	if r == nil {
+		return len(f.Statuses) == 0 && f.UnitCode == "" && f.Type == "" && f.Provider == "" &&
+			f.Region == "" && f.Environment == "" && f.Function == ""
+	}
+
+	if len(f.Statuses) > 0 && !containsFold(f.Statuses, r.Status) {
+		return false
+	}
+
+	segments := []struct{ want, got string }{
+		{f.UnitCode, r.UnitCode},
+		{f.Type, r.Type},
+		{f.Provider, r.Provider},
+		{f.Region, r.Region},
+		{f.Environment, r.Environment},
+		{f.Function, r.Function},
+	}
+	for _, s := range segments {
+		if s.want != "" && !strings.EqualFold(s.want, s.got) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// EventBroker polls the reservation history for new events and fans them
+// out to live subscribers. Polling the database means events made by every
+// server instance, and by background workers, reach every stream.
+type EventBroker struct {
+	eventModel *models.EventModel
+	interval   time.Duration
+	logger     *utils.Logger
+
+	mu          sync.Mutex
+	subscribers map[chan *models.ReservationEvent]struct{}
+	stopped     bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewEventBroker creates a broker that polls for new events every interval
+func NewEventBroker(eventModel *models.EventModel, interval time.Duration, logger *utils.Logger) *EventBroker {
+	return &EventBroker{
+		eventModel:  eventModel,
+		interval:    interval,
+		logger:      logger,
+		subscribers: make(map[chan *models.ReservationEvent]struct{}),
+	}
+}
+
+// Start launches the broker in a background goroutine
+func (b *EventBroker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.run(ctx)
+	}()
+
+	b.logger.Info("Event broker started", "interval", b.interval.String())
+}
+
+// Stop stops polling and closes every subscription
+func (b *EventBroker) Stop() {
+	if b.cancel == nil {
+		return
+	}
+	b.cancel()
+	b.wg.Wait()
+
+	b.mu.Lock()
+	b.stopped = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+	b.mu.Unlock()
+
+	b.logger.Info("Event broker stopped")
+}
+
+// Subscribe registers a subscriber with room for buffer pending events. The
+// channel is closed when the subscriber falls behind by more than buffer
+// events, when unsubscribe is called or when the broker stops.
+func (b *EventBroker) Subscribe(buffer int) (<-chan *models.ReservationEvent, func()) {
+	ch := make(chan *models.ReservationEvent, buffer)
+
+	b.mu.Lock()
+	if b.stopped {
+		close(ch)
+	} else {
+		b.subscribers[ch] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// Replay returns the next page of up to EventReplayPageSize stored events
+// with an ID above afterID. A page shorter than that has reached the newest
+// stored event.
+func (b *EventBroker) Replay(ctx context.Context, afterID int64) ([]*models.ReservationEvent, error) {
+	return b.eventModel.GetAfter(ctx, afterID, EventReplayPageSize)
+}
+
+// LatestID returns the ID of the newest stored event
+func (b *EventBroker) LatestID(ctx context.Context) (int64, error) {
+	return b.eventModel.GetLatestID(ctx)
+}
+
+// publish sends an event to every subscriber, dropping subscribers whose
+// buffer is full rather than blocking the others
+func (b *EventBroker) publish(e *models.ReservationEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+			b.logger.Warn("Dropped slow event stream subscriber")
+		}
+	}
+}
+
+// run polls from the newest stored event until ctx is cancelled
+func (b *EventBroker) run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	var cursor int64
+	for {
+		latest, err := b.eventModel.GetLatestID(ctx)
+		if err == nil {
+			cursor = latest
+			break
+		}
+		if ctx.Err() == nil {
+			b.logger.Error("Failed to start event broker", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+
+	// IDs above the cursor that were already published, and since when the
+	// lowest missing ID has been missing
+	seen := make(map[int64]bool)
+	var gapID int64
+	var gapSince time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		events, err := b.eventModel.GetAfter(ctx, cursor, eventPollBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				b.logger.Error("Failed to poll reservation events", "error", err)
+			}
+			continue
+		}
+
+		for _, e := range events {
+			if !seen[e.ID] {
+				seen[e.ID] = true
+				b.publish(e)
+			}
+		}
+
+		// IDs are allocated before commit, so a missing ID may still be
+		// committed shortly. Only move past a gap once it has timed out.
+		for {
+			for seen[cursor+1] {
+				delete(seen, cursor+1)
+				cursor++
+			}
+			if len(seen) == 0 {
+				break
+			}
+			if gapID != cursor+1 {
+				gapID = cursor + 1
+				gapSince = time.Now()
+				break
+			}
+			if time.Since(gapSince) < eventGapTimeout {
+				break
+			}
+			cursor++
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+	"github.com/google/uuid"
+)
+
+// transitionEvents maps lifecycle actions to the event they record
+var transitionEvents = map[string]string{
+	models.ActionCommit:       models.EventCommitted,
+	models.ActionRelease:      models.EventReleased,
+	models.ActionExpire:       models.EventExpired,
+	models.ActionDecommission: models.EventDecommissioned,
+	models.ActionRecommission: models.EventRecommissioned,
+	models.ActionRetire:       models.EventRetired,
+	models.ActionDelete:       models.EventDeleted,
+}
+
+// recordEvent appends a reservation event inside tx, queues it for webhook
+// subscribers and writes it to the outbox. The actor and request ID are taken from ctx;
+// background changes have neither.
+func (s *NameGeneratorService) recordEvent(ctx context.Context, tx *sql.Tx, eventType string, before, after *models.Reservation) error {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	event := &models.ReservationEvent{
+		ReservationID: subject.ID,
+		ServerName:    subject.ServerName,
+		Type:          eventType,
+		Before:        before,
+		After:         after,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if principal, ok := utils.PrincipalFromContext(ctx); ok {
+		event.Actor = principal.UserID
+		event.APIKeyID = principal.APIKeyID
+	}
+	if requestID, ok := ctx.Value(utils.RequestIDKey).(string); ok {
+		event.RequestID = requestID
+	}
+
+	if err := s.eventModel.Record(ctx, tx, event); err != nil {
+		return err
+	}
+	if err := s.webhookModel.Enqueue(ctx, tx, event); err != nil {
+		return err
+	}
+	if s.outboxModel != nil {
+		return s.outboxModel.Append(ctx, tx, event)
+	}
+	return nil
+}
+
+// GetReservationHistory returns the events of a reservation, oldest first.
+// History is kept after a reservation is deleted.
+func (s *NameGeneratorService) GetReservationHistory(ctx context.Context, id string) (_ []*models.ReservationEvent, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.GetReservationHistory")
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("Reservation %s not found", id))
+	}
+
+	events, err := s.eventModel.GetByReservation(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("Failed to get reservation history", err)
+	}
+
+	if len(events) == 0 {
+		reservation, err := s.reservationModel.GetByID(ctx, id)
+		if err != nil {
+			return nil, errors.NewDatabaseError("Failed to get reservation", err)
+		}
+		if reservation == nil {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("Reservation %s not found", id))
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+// Export formats
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+	ExportYAML   = "yaml"
+)
+
+// ExportContentTypes maps each export format to its content type
+var ExportContentTypes = map[string]string{
+	ExportCSV:    "text/csv; charset=utf-8",
+	ExportNDJSON: "application/x-ndjson",
+	ExportYAML:   "application/yaml",
+}
+
+// exportFlushInterval is how many rows are written between flushes
+const exportFlushInterval = 500
+
+// exportRecord is the exported form of a reservation
+type exportRecord struct {
+	ID              string            `json:"id" yaml:"id"`
+	ServerName      string            `json:"serverName" yaml:"serverName"`
+	UnitCode        string            `json:"unitCode" yaml:"unitCode"`
+	Type            string            `json:"type" yaml:"type"`
+	Provider        string            `json:"provider" yaml:"provider"`
+	Region          string            `json:"region" yaml:"region"`
+	Environment     string            `json:"environment" yaml:"environment"`
+	Function        string            `json:"function" yaml:"function"`
+	SequenceNum     int               `json:"sequenceNum" yaml:"sequenceNum"`
+	Status          string            `json:"status" yaml:"status"`
+	SchemeID        string            `json:"schemeId,omitempty" yaml:"schemeId,omitempty"`
+	CreatedAt       string            `json:"createdAt" yaml:"createdAt"`
+	UpdatedAt       string            `json:"updatedAt" yaml:"updatedAt"`
+	CommittedAt     string            `json:"committedAt,omitempty" yaml:"committedAt,omitempty"`
+	ExpiresAt       string            `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
+	QuarantineUntil string            `json:"quarantineUntil,omitempty" yaml:"quarantineUntil,omitempty"`
+	CreatedBy       string            `json:"createdBy,omitempty" yaml:"createdBy,omitempty"`
+	CommittedBy     string            `json:"committedBy,omitempty" yaml:"committedBy,omitempty"`
+	APIKeyID        string            `json:"apiKeyId,omitempty" yaml:"apiKeyId,omitempty"`
+	Description     string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Ticket          string            `json:"ticket,omitempty" yaml:"ticket,omitempty"`
+	RequestedFor    string            `json:"requestedFor,omitempty" yaml:"requestedFor,omitempty"`
+	Tags            map[string]string `json:"tags" yaml:"tags"`
+}
+
+// exportColumns is the CSV header, in exportRecord field order
+var exportColumns = []string{
+	"id", "serverName", "unitCode", "type", "provider", "region", "environment",
+	"function", "sequenceNum", "status", "schemeId", "createdAt", "updatedAt",
+	"committedAt", "expiresAt", "quarantineUntil", "createdBy", "committedBy",
+	"apiKeyId", "description", "ticket", "requestedFor", "tags",
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func newExportRecord(r *models.Reservation) exportRecord {
+	return exportRecord{
+		ID:              r.ID,
+		ServerName:      r.ServerName,
+		UnitCode:        r.UnitCode,
+		Type:            r.Type,
+		Provider:        r.Provider,
+		Region:          r.Region,
+		Environment:     r.Environment,
+		Function:        r.Function,
+		SequenceNum:     r.SequenceNum,
+		Status:          r.Status,
+		SchemeID:        r.SchemeID,
+		CreatedAt:       formatExportTime(&r.CreatedAt),
+		UpdatedAt:       formatExportTime(&r.UpdatedAt),
+		CommittedAt:     formatExportTime(r.CommittedAt),
+		ExpiresAt:       formatExportTime(r.ExpiresAt),
+		QuarantineUntil: formatExportTime(r.QuarantineUntil),
+		CreatedBy:       r.CreatedBy,
+		CommittedBy:     r.CommittedBy,
+		APIKeyID:        r.APIKeyID,
+		Description:     r.Description,
+		Ticket:          r.Ticket,
+		RequestedFor:    r.RequestedFor,
+		Tags:            r.Tags,
+	}
+}
+
+// csvTags renders tags as key=value pairs sorted by key, separated by semicolons
+func csvTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
+
+// reservationEncoder writes reservations in one export format
+type reservationEncoder interface {
+	Encode(r *models.Reservation) error
+	Flush() error
+}
+
+func newReservationEncoder(format string, w io.Writer) (reservationEncoder, error) {
+	switch format {
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportColumns); err != nil {
+			return nil, err
+		}
+		return &csvEncoder{w: cw}, nil
+	case ExportNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	case ExportYAML:
+		return &yamlEncoder{w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Encode(r *models.Reservation) error {
+	rec := newExportRecord(r)
+	return e.w.Write([]string{
+		rec.ID, rec.ServerName, rec.UnitCode, rec.Type, rec.Provider, rec.Region,
+		rec.Environment, rec.Function, strconv.Itoa(rec.SequenceNum), rec.Status,
+		rec.SchemeID, rec.CreatedAt, rec.UpdatedAt, rec.CommittedAt, rec.ExpiresAt,
+		rec.QuarantineUntil, rec.CreatedBy, rec.CommittedBy, rec.APIKeyID,
+		rec.Description, rec.Ticket, rec.RequestedFor, csvTags(rec.Tags),
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(r *models.Reservation) error {
+	return e.enc.Encode(newExportRecord(r))
+}
+
+func (e *ndjsonEncoder) Flush() error { return nil }
+
+// yamlEncoder writes a single YAML sequence, one item per reservation
+type yamlEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *yamlEncoder) Encode(r *models.Reservation) error {
+	data, err := yaml.Marshal(newExportRecord(r))
+	if err != nil {
+		return err
+	}
+
+	// Indent the mapping as a sequence item
+	var b bytes.Buffer
+	for i, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if i == 0 {
+			b.WriteString("- ")
+		} else {
+			b.WriteString("  ")
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+
+	e.count++
+	_, err = e.w.Write(b.Bytes())
+	return err
+}
+
+func (e *yamlEncoder) Flush() error {
+	return nil
+}
+
+// flusher is implemented by writers that can push buffered data to the
+// client, such as http.ResponseWriter
+type flusher interface {
+	Flush()
+}
+
+// ExportReservations streams every reservation matching a filter to w in the
+// given format, oldest first. Rows are read and written one at a time so
+// large exports use constant memory. It returns the number of rows written.
+func (s *NameGeneratorService) ExportReservations(ctx context.Context, filter models.ReservationFilter, format string, w io.Writer) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.ExportReservations")
+	defer func() { tracing.End(span, err) }()
+
+	enc, err := newReservationEncoder(format, w)
+	if err != nil {
+		return 0, errors.NewValidationError(err.Error(), err)
+	}
+
+	count := 0
+	flush := func() error {
+		if err := enc.Flush(); err != nil {
+			return err
+		}
+		if f, ok := w.(flusher); ok {
+			f.Flush()
+		}
+		return nil
+	}
+
+	err = s.reservationModel.Each(ctx, normalizeFilter(filter), func(r *models.Reservation) error {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushInterval == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return count, errors.NewInternalError("Failed to export reservations", err)
+	}
+
+	// An empty YAML export is an empty sequence
+	if y, ok := enc.(*yamlEncoder); ok && y.count == 0 {
+		if _, err := io.WriteString(w, "[]\n"); err != nil {
+			return 0, errors.NewInternalError("Failed to export reservations", err)
+		}
+	}
+
+	if err := flush(); err != nil {
+		return count, errors.NewInternalError("Failed to export reservations", err)
+	}
+
+	return count, nil
+}
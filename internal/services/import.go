@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/db"
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// Import formats
+const (
+	ImportCSV  = "csv"
+	ImportJSON = "json"
+)
+
+// ParseImportRows reads import rows from CSV or a JSON array. CSV input needs
+// a header row naming its columns: serverName is required, and scheme,
+// schemeVersion, description, ticket, requestedFor and tags (key=value pairs
+// separated by ";") are optional.
+func ParseImportRows(format string, r io.Reader) ([]models.ImportRow, error) {
+	var rows []models.ImportRow
+
+	switch format {
+	case ImportJSON:
+		if err := json.NewDecoder(r).Decode(&rows); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	case ImportCSV:
+		var err error
+		if rows, err = parseImportCSV(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+
+	if len(rows) > models.MaxImportRows {
+		return nil, fmt.Errorf("an import may contain at most %d rows", models.MaxImportRows)
+	}
+	return rows, nil
+}
+
+func parseImportCSV(r io.Reader) ([]models.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["servername"]; !ok {
+		return nil, fmt.Errorf("CSV header has no serverName column")
+	}
+
+	var rows []models.ImportRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		get := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := models.ImportRow{
+			ServerName: get("servername"),
+			Scheme:     get("scheme"),
+		}
+		row.Description = get("description")
+		row.Ticket = get("ticket")
+		row.RequestedFor = get("requestedfor")
+
+		if version := get("schemeversion"); version != "" {
+			if row.SchemeVersion, err = strconv.Atoi(version); err != nil {
+				return nil, fmt.Errorf("line %d: schemeVersion must be a number", line)
+			}
+		}
+
+		if tags := get("tags"); tags != "" {
+			row.Tags = make(map[string]string)
+			for _, pair := range strings.Split(tags, ";") {
+				key, value, _ := strings.Cut(pair, "=")
+				row.Tags[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// ImportServerNames records existing server names as committed reservations
+// and advances their sequence counters. Each row is imported in its own
+// transaction, so one bad row does not stop the rest. In a dry run every row
+// is checked and rolled back. Names repeated within the import are reported
+// as conflicts after their first occurrence.
+func (s *NameGeneratorService) ImportServerNames(ctx context.Context, rows []models.ImportRow, dryRun bool) (_ *models.ImportReport, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.ImportServerNames")
+	defer func() { tracing.End(span, err) }()
+
+	candidates, err := s.decodeCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{DryRun: dryRun, Rows: make([]*models.ImportResult, 0, len(rows))}
+	seen := make(map[string]int)
+
+	for i, row := range rows {
+		result := s.importRow(ctx, row, candidates, seen, dryRun)
+		result.Row = i + 1
+
+		switch result.Status {
+		case models.ImportAccepted:
+			report.Accepted++
+			seen[strings.ToUpper(result.ServerName)] = result.Row
+		case models.ImportConflict:
+			report.Conflicts++
+		default:
+			report.Rejected++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	s.logger.Info("Server names imported",
+		"dryRun", dryRun,
+		"accepted", report.Accepted,
+		"rejected", report.Rejected,
+		"conflicts", report.Conflicts,
+	)
+	return report, nil
+}
+
+// importRow imports a single row and reports its outcome
+func (s *NameGeneratorService) importRow(ctx context.Context, row models.ImportRow, candidates []*models.NamingScheme, seen map[string]int, dryRun bool) *models.ImportResult {
+	result := &models.ImportResult{ServerName: row.ServerName}
+	reject := func(err error) *models.ImportResult {
+		result.Status = models.ImportRejected
+		if appErr, ok := err.(*errors.AppError); ok {
+			result.Error = appErr.Message
+			if appErr.Type == errors.ErrorTypeConflict {
+				result.Status = models.ImportConflict
+			}
+		} else {
+			result.Error = err.Error()
+		}
+		return result
+	}
+
+	if err := utils.Validate(row); err != nil {
+		return reject(err)
+	}
+	if err := models.ValidateTags(row.Tags); err != nil {
+		return reject(err)
+	}
+
+	if row.Scheme != "" {
+		scheme, err := s.ResolveScheme(ctx, row.Scheme, row.SchemeVersion)
+		if err != nil {
+			return reject(err)
+		}
+		candidates = []*models.NamingScheme{scheme}
+	}
+
+	// Use the first scheme the name fits, remembering why the others failed
+	var scheme *models.NamingScheme
+	var normalized models.ReservationPayload
+	var sequenceNum int
+	var lastErr error
+	for _, candidate := range candidates {
+		parsed, seq, ok := s.ParseServerName(candidate, row.ServerName)
+		if !ok {
+			continue
+		}
+		n, err := s.NormalizePayload(candidate, parsed)
+		if err == nil {
+			err = s.ValidateCatalogs(ctx, candidate, n)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		scheme, normalized, sequenceNum = candidate, n, seq
+		break
+	}
+	if scheme == nil {
+		if lastErr != nil {
+			return reject(lastErr)
+		}
+		return reject(fmt.Errorf("server name does not conform to any active naming scheme"))
+	}
+	result.Scheme = scheme.Name
+
+	serverName := s.GenerateServerName(scheme, normalized, sequenceNum)
+	result.ServerName = serverName
+	if first, ok := seen[strings.ToUpper(serverName)]; ok {
+		return reject(errors.NewConflictError(fmt.Sprintf("Server name %s repeats row %d", serverName, first)))
+	}
+
+	existing, err := s.reservationModel.GetByServerName(ctx, serverName)
+	if err != nil {
+		return reject(errors.NewDatabaseError("Failed to look up server name", err))
+	}
+	if existing != nil && existing.Status != models.StatusExpired {
+		return reject(errors.NewConflictError(
+			fmt.Sprintf("Server name %s is already %s", serverName, existing.Status)))
+	}
+
+	prepared := &preparedReservation{
+		scheme:  scheme,
+		params:  normalized,
+		details: row.ReservationDetails,
+		tags:    row.Tags,
+	}
+	key := models.SequenceKeyFor(normalized)
+
+	err = s.txRunner.Run(ctx, "ImportServerName", func(tx *sql.Tx) error {
+		reservation, err := s.createReservation(ctx, tx, prepared, sequenceNum, serverName)
+		if err != nil {
+			return err
+		}
+		result.ReservationID = reservation.ID
+
+		if _, err := s.applyTransition(ctx, tx, reservation.ID, models.ActionCommit, lifecycleChange{}); err != nil {
+			return err
+		}
+
+		if err := s.sequenceModel.AdvanceSequenceNumber(ctx, tx, key, sequenceNum); err != nil {
+			return errors.NewDatabaseError("Failed to update sequence", err)
+		}
+
+		if dryRun {
+			return db.ErrRollback
+		}
+		return nil
+	})
+	if err != nil {
+		result.ReservationID = ""
+		return reject(transactionError(err, "Failed to import server name"))
+	}
+
+	if dryRun {
+		result.ReservationID = ""
+	}
+	result.Status = models.ImportAccepted
+	return result
+}
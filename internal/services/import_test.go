@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+)
+
+func TestParseImportRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		want    []models.ImportRow
+		wantErr bool
+	}{
+		{
+			name:   "csv with every column",
+			format: ImportCSV,
+			input: "serverName,scheme,schemeVersion,description,ticket,requestedFor,tags\n" +
+				"ABCVAWEU1PWB007,compact,2,Billing web,CHG-1,alice,app=billing;env=prod\n",
+			want: []models.ImportRow{{
+				ServerName:    "ABCVAWEU1PWB007",
+				Scheme:        "compact",
+				SchemeVersion: 2,
+				ReservationDetails: models.ReservationDetails{
+					Description: "Billing web", Ticket: "CHG-1", RequestedFor: "alice",
+				},
+				Tags: map[string]string{"app": "billing", "env": "prod"},
+			}},
+		},
+		{
+			name:   "csv header is case-insensitive and reorderable",
+			format: ImportCSV,
+			input:  "Ticket, SERVERNAME\nCHG-2, abc-weu-p-wb-001\n",
+			want: []models.ImportRow{{
+				ServerName:         "abc-weu-p-wb-001",
+				ReservationDetails: models.ReservationDetails{Ticket: "CHG-2"},
+			}},
+		},
+		{
+			name:   "csv quoted fields and trimmed tags",
+			format: ImportCSV,
+			input:  "serverName,description,tags\n\"ABCVAWEU1PWB008\",\"web, primary\",\" team = web ; legacy \"\n",
+			want: []models.ImportRow{{
+				ServerName:         "ABCVAWEU1PWB008",
+				ReservationDetails: models.ReservationDetails{Description: "web, primary"},
+				Tags:               map[string]string{"team": "web", "legacy": ""},
+			}},
+		},
+		{
+			name:   "csv multiple rows",
+			format: ImportCSV,
+			input:  "serverName\nONE\nTWO\n",
+			want:   []models.ImportRow{{ServerName: "ONE"}, {ServerName: "TWO"}},
+		},
+		{name: "csv header only", format: ImportCSV, input: "serverName\n"},
+		{name: "csv empty", format: ImportCSV, input: "", wantErr: true},
+		{name: "csv without serverName", format: ImportCSV, input: "name\nONE\n", wantErr: true},
+		{name: "csv bad schemeVersion", format: ImportCSV, input: "serverName,schemeVersion\nONE,two\n", wantErr: true},
+		{name: "csv ragged row", format: ImportCSV, input: "serverName,ticket\nONE\n", wantErr: true},
+		{name: "csv unterminated quote", format: ImportCSV, input: "serverName\n\"ONE\n", wantErr: true},
+		{
+			name:   "json",
+			format: ImportJSON,
+			input:  `[{"serverName":"ONE","scheme":"compact","ticket":"CHG-3","tags":{"app":"billing"}}]`,
+			want: []models.ImportRow{{
+				ServerName:         "ONE",
+				Scheme:             "compact",
+				ReservationDetails: models.ReservationDetails{Ticket: "CHG-3"},
+				Tags:               map[string]string{"app": "billing"},
+			}},
+		},
+		{name: "json not an array", format: ImportJSON, input: `{"serverName":"ONE"}`, wantErr: true},
+		{name: "unknown format", format: "xml", input: "<rows/>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseImportRows(tt.format, strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseImportRows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseImportRows() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportRowsLimit(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("serverName\n")
+	for i := 0; i <= models.MaxImportRows; i++ {
+		fmt.Fprintf(&b, "NAME%d\n", i)
+	}
+
+	if _, err := ParseImportRows(ImportCSV, strings.NewReader(b.String())); err == nil {
+		t.Errorf("ParseImportRows() accepted %d rows, want an error", models.MaxImportRows+1)
+	}
+}
+
+func TestImportServerNames(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	existing, err := s.ReserveServerName(ctx, webPayload)
+	if err != nil {
+		t.Fatalf("ReserveServerName() error = %v", err)
+	}
+
+	rows := []models.ImportRow{
+		{ServerName: existing.ServerName},
+		{ServerName: "ABCVAWEU1PWB042", Tags: map[string]string{"source": "cmdb"}},
+		{ServerName: "abcvaweu1pwb042"},
+		{ServerName: "abc-weu1-p-wb-007"},
+		{ServerName: "not a server"},
+		{ServerName: "ABCVAWEU1PWB010", Scheme: "linux"},
+	}
+	wantStatus := []string{
+		models.ImportConflict,
+		models.ImportAccepted,
+		models.ImportConflict,
+		models.ImportAccepted,
+		models.ImportRejected,
+		models.ImportRejected,
+	}
+
+	check := func(t *testing.T, report *models.ImportReport, dryRun bool) {
+		t.Helper()
+		if report.Accepted != 2 || report.Conflicts != 2 || report.Rejected != 2 {
+			t.Errorf("report counts = %d accepted, %d conflicts, %d rejected; want 2 of each",
+				report.Accepted, report.Conflicts, report.Rejected)
+		}
+		for i, result := range report.Rows {
+			if result.Row != i+1 || result.Status != wantStatus[i] {
+				t.Errorf("row %d (%s) = %s, want %s: %s", result.Row, rows[i].ServerName, result.Status, wantStatus[i], result.Error)
+			}
+			if hasID := result.ReservationID != ""; hasID != (result.Status == models.ImportAccepted && !dryRun) {
+				t.Errorf("row %d reservation ID = %q in a dry run = %v", result.Row, result.ReservationID, dryRun)
+			}
+		}
+	}
+
+	report, err := s.ImportServerNames(ctx, rows, true)
+	if err != nil {
+		t.Fatalf("ImportServerNames() dry run error = %v", err)
+	}
+	check(t, report, true)
+	if r, err := s.reservationModel.GetByServerName(ctx, "ABCVAWEU1PWB042"); err != nil || r != nil {
+		t.Fatalf("dry run stored %v, %v", r, err)
+	}
+
+	report, err = s.ImportServerNames(ctx, rows, false)
+	if err != nil {
+		t.Fatalf("ImportServerNames() error = %v", err)
+	}
+	check(t, report, false)
+
+	imported, err := s.reservationModel.GetByServerName(ctx, "ABCVAWEU1PWB042")
+	if err != nil || imported == nil {
+		t.Fatalf("GetByServerName() = %v, %v", imported, err)
+	}
+	if imported.Status != models.StatusCommitted || imported.Tags["source"] != "cmdb" {
+		t.Errorf("imported reservation = %s with tags %v, want committed with the row's tags", imported.Status, imported.Tags)
+	}
+
+	// The counter moves past imported numbers so they are not handed out again
+	next, err := s.ReserveServerName(ctx, webPayload)
+	if err != nil {
+		t.Fatalf("ReserveServerName() error = %v", err)
+	}
+	if next.ServerName != "ABCVAWEU1PWB043" {
+		t.Errorf("ReserveServerName() after import = %q, want ABCVAWEU1PWB043", next.ServerName)
+	}
+
+	// Importing the same rows again only finds conflicts and rejects
+	report, err = s.ImportServerNames(ctx, rows, false)
+	if err != nil {
+		t.Fatalf("ImportServerNames() again error = %v", err)
+	}
+	if report.Accepted != 0 || report.Conflicts != 4 {
+		t.Errorf("re-import accepted %d with %d conflicts, want 0 and 4", report.Accepted, report.Conflicts)
+	}
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/metrics"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// ReservationLifetimes controls how long reservations stay in time-limited states
+type ReservationLifetimes struct {
+	TTL        time.Duration // Lifetime of uncommitted reservations, 0 to never expire
+	Quarantine time.Duration // How long decommissioned names are kept from reuse
+}
+
+// lifecycleChange describes the extra effects of a lifecycle action
+type lifecycleChange struct {
+	tags            map[string]string // Commit: tags merged into the reservation
+	quarantineUntil *time.Time        // Decommission: end of the quarantine
+}
+
+// applyTransition locks the reservation, checks the action against the
+// lifecycle state machine and applies it inside tx. It returns the
+// reservation as it was before the action.
+func (s *NameGeneratorService) applyTransition(ctx context.Context, tx *sql.Tx, id, action string, change lifecycleChange) (*models.Reservation, error) {
+	reservation, err := s.reservationModel.GetByIDForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("Failed to get reservation", err)
+	}
+	if reservation == nil {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("Reservation %s not found", id))
+	}
+
+	to, err := models.Transition(reservation, action, time.Now().UTC())
+	if err != nil {
+		if te, ok := err.(*models.TransitionError); ok {
+			return nil, errors.NewConflictError(capitalize(te.Error())).WithCode(te.Code)
+		}
+		return nil, errors.NewInternalError("Failed to apply lifecycle action", err)
+	}
+
+	switch action {
+	case models.ActionCommit:
+		committedBy := ""
+		if principal, ok := utils.PrincipalFromContext(ctx); ok {
+			committedBy = principal.UserID
+		}
+		err = s.reservationModel.Commit(ctx, tx, id, committedBy, change.tags)
+	case models.ActionRelease:
+		err = s.reservationModel.Release(ctx, tx, id, s.expiresAt(0))
+	case models.ActionDelete:
+		err = s.deleteReservation(ctx, tx, reservation)
+	default:
+		err = s.reservationModel.SetLifecycleStatus(ctx, tx, id, reservation.Status, to, change.quarantineUntil)
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError("Failed to update reservation", err)
+	}
+
+	var after *models.Reservation
+	if to != models.StatusDeleted {
+		if after, err = s.reservationModel.GetByIDForUpdate(ctx, tx, id); err != nil {
+			return nil, errors.NewDatabaseError("Failed to reload reservation", err)
+		}
+	}
+	if err := s.recordEvent(ctx, tx, transitionEvents[action], reservation, after); err != nil {
+		return nil, errors.NewDatabaseError("Failed to record reservation event", err)
+	}
+
+	return reservation, nil
+}
+
+// runTransition applies a lifecycle action in its own transaction
+func (s *NameGeneratorService) runTransition(ctx context.Context, id, action string, change lifecycleChange) (*models.Reservation, error) {
+	var reservation *models.Reservation
+	err := s.txRunner.Run(ctx, action, func(tx *sql.Tx) error {
+		var err error
+		reservation, err = s.applyTransition(ctx, tx, id, action, change)
+		return err
+	})
+	if err != nil {
+		return nil, transactionError(err, "Failed to "+action+" reservation")
+	}
+
+	s.logger.Info("Reservation lifecycle action applied",
+		"action", action,
+		"id", id,
+		"serverName", reservation.ServerName,
+		"from", reservation.Status,
+	)
+	return reservation, nil
+}
+
+// deleteReservation removes a reservation inside tx. The number of a name
+// that was ever committed is remembered so gap-filling honours the reuse
+// cool-down.
+func (s *NameGeneratorService) deleteReservation(ctx context.Context, tx *sql.Tx, reservation *models.Reservation) error {
+	if err := s.reservationModel.Delete(ctx, tx, reservation.ID); err != nil {
+		return err
+	}
+
+	if reservation.CommittedAt != nil {
+		key := models.SequenceKey{
+			UnitCode:    reservation.UnitCode,
+			Type:        reservation.Type,
+			Provider:    reservation.Provider,
+			Region:      reservation.Region,
+			Environment: reservation.Environment,
+			Function:    reservation.Function,
+		}
+		if err := s.sequenceModel.RecordTombstone(ctx, tx, key, reservation.SequenceNum, *reservation.CommittedAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CommitReservation commits a server name reservation, merging tags into the
+// tags given when it was reserved
+func (s *NameGeneratorService) CommitReservation(ctx context.Context, reservationID string, tags map[string]string) (err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.CommitReservation")
+	defer func() { tracing.End(span, err) }()
+	defer func() { metrics.RecordOperation(metrics.OperationCommit, err) }()
+
+	if err := models.ValidateTags(tags); err != nil {
+		return errors.NewValidationError(err.Error(), err)
+	}
+
+	_, err = s.runTransition(ctx, reservationID, models.ActionCommit, lifecycleChange{tags: tags})
+	return err
+}
+
+// ReleaseReservation changes a reservation status from committed back to
+// reserved. The reservation gets a fresh TTL.
+func (s *NameGeneratorService) ReleaseReservation(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.ReleaseReservation")
+	defer func() { tracing.End(span, err) }()
+	defer func() { metrics.RecordOperation(metrics.OperationRelease, err) }()
+
+	_, err = s.runTransition(ctx, id, models.ActionRelease, lifecycleChange{})
+	return err
+}
+
+// DeleteReservation deletes a reservation that is reserved, expired, or
+// decommissioned with its quarantine over
+func (s *NameGeneratorService) DeleteReservation(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.DeleteReservation")
+	defer func() { tracing.End(span, err) }()
+
+	_, err = s.runTransition(ctx, id, models.ActionDelete, lifecycleChange{})
+	return err
+}
+
+// DecommissionReservation takes a committed name out of service. The name
+// stays quarantined for the given period, or the configured default when it
+// is 0, before it can be reused.
+func (s *NameGeneratorService) DecommissionReservation(ctx context.Context, id string, quarantine time.Duration) (err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.DecommissionReservation")
+	defer func() { tracing.End(span, err) }()
+
+	if quarantine <= 0 {
+		quarantine = s.lifetimes.Quarantine
+	}
+	until := time.Now().UTC().Add(quarantine)
+
+	_, err = s.runTransition(ctx, id, models.ActionDecommission, lifecycleChange{quarantineUntil: &until})
+	return err
+}
+
+// RecommissionReservation puts a decommissioned name back into service
+func (s *NameGeneratorService) RecommissionReservation(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.RecommissionReservation")
+	defer func() { tracing.End(span, err) }()
+
+	_, err = s.runTransition(ctx, id, models.ActionRecommission, lifecycleChange{})
+	return err
+}
+
+// RetireReservation permanently retires a committed or decommissioned name
+func (s *NameGeneratorService) RetireReservation(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.RetireReservation")
+	defer func() { tracing.End(span, err) }()
+
+	_, err = s.runTransition(ctx, id, models.ActionRetire, lifecycleChange{})
+	return err
+}
+
+// ReclaimQuarantinedNames deletes decommissioned reservations whose
+// quarantine has ended so their names can be reused, and returns how many
+// were reclaimed
+func (s *NameGeneratorService) ReclaimQuarantinedNames(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.ReclaimQuarantinedNames")
+	defer func() { tracing.End(span, err) }()
+
+	total := 0
+	for {
+		var reclaimed []*models.Reservation
+		err := s.txRunner.Run(ctx, "ReclaimQuarantinedNames", func(tx *sql.Tx) error {
+			reclaimed = nil
+			candidates, err := s.reservationModel.FindQuarantineEnded(ctx, tx, time.Now().UTC(), expiryBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, r := range candidates {
+				if _, err := models.Transition(r, models.ActionDelete, time.Now().UTC()); err != nil {
+					continue
+				}
+				if err := s.deleteReservation(ctx, tx, r); err != nil {
+					return err
+				}
+				if err := s.recordEvent(ctx, tx, models.EventReclaimed, r, nil); err != nil {
+					return err
+				}
+				reclaimed = append(reclaimed, r)
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+
+		for _, r := range reclaimed {
+			s.logger.Debug("Quarantined name reclaimed", "id", r.ID, "serverName", r.ServerName)
+		}
+
+		total += len(reclaimed)
+		if len(reclaimed) < expiryBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// capitalize upper-cases the first letter of an error message
+func capitalize(message string) string {
+	if message == "" {
+		return message
+	}
+	return strings.ToUpper(message[:1]) + message[1:]
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+)
+
+func TestReservationLifecycle(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	resp, err := s.ReserveServerName(ctx, webPayload)
+	if err != nil {
+		t.Fatalf("ReserveServerName() error = %v", err)
+	}
+	id := resp.ReservationID
+
+	// load fetches the stored reservation, failing the test if it is gone
+	load := func(t *testing.T) *models.Reservation {
+		t.Helper()
+		r, err := s.reservationModel.GetByID(ctx, id)
+		if err != nil || r == nil {
+			t.Fatalf("GetByID() = %v, %v", r, err)
+		}
+		return r
+	}
+
+	t.Run("reserved names cannot be decommissioned", func(t *testing.T) {
+		err := s.DecommissionReservation(ctx, id, 0)
+		if code := errorCode(err); code != "invalid_transition" {
+			t.Fatalf("DecommissionReservation() error = %v, want invalid_transition", err)
+		}
+		if got := load(t).Status; got != models.StatusReserved {
+			t.Errorf("status = %q, want %q", got, models.StatusReserved)
+		}
+	})
+
+	t.Run("decommission starts the quarantine", func(t *testing.T) {
+		if err := s.CommitReservation(ctx, id, nil); err != nil {
+			t.Fatalf("CommitReservation() error = %v", err)
+		}
+		before := time.Now().UTC()
+		if err := s.DecommissionReservation(ctx, id, 0); err != nil {
+			t.Fatalf("DecommissionReservation() error = %v", err)
+		}
+
+		r := load(t)
+		if r.Status != models.StatusDecommissioned {
+			t.Errorf("status = %q, want %q", r.Status, models.StatusDecommissioned)
+		}
+		if r.QuarantineUntil == nil || r.QuarantineUntil.Before(before.Add(time.Hour)) {
+			t.Errorf("quarantine until = %v, want the configured hour", r.QuarantineUntil)
+		}
+	})
+
+	t.Run("quarantined names cannot be deleted", func(t *testing.T) {
+		err := s.DeleteReservation(ctx, id)
+		if code := errorCode(err); code != "name_quarantined" {
+			t.Fatalf("DeleteReservation() error = %v, want name_quarantined", err)
+		}
+		load(t)
+	})
+
+	t.Run("recommission", func(t *testing.T) {
+		if err := s.RecommissionReservation(ctx, id); err != nil {
+			t.Fatalf("RecommissionReservation() error = %v", err)
+		}
+		if r := load(t); r.Status != models.StatusCommitted || r.QuarantineUntil != nil {
+			t.Errorf("status, quarantine = %q, %v; want committed without quarantine", r.Status, r.QuarantineUntil)
+		}
+	})
+
+	t.Run("reclaim after quarantine", func(t *testing.T) {
+		if err := s.DecommissionReservation(ctx, id, time.Minute); err != nil {
+			t.Fatalf("DecommissionReservation() error = %v", err)
+		}
+		if n, err := s.ReclaimQuarantinedNames(ctx); err != nil || n != 0 {
+			t.Fatalf("ReclaimQuarantinedNames() during quarantine = %d, %v; want 0", n, err)
+		}
+
+		if _, err := s.db.ExecContext(ctx,
+			`UPDATE reservations SET quarantine_until = NOW() - INTERVAL '1 second' WHERE id = $1`, id); err != nil {
+			t.Fatalf("failed to end quarantine: %v", err)
+		}
+		if n, err := s.ReclaimQuarantinedNames(ctx); err != nil || n != 1 {
+			t.Fatalf("ReclaimQuarantinedNames() = %d, %v; want 1", n, err)
+		}
+		if r, err := s.reservationModel.GetByID(ctx, id); err != nil || r != nil {
+			t.Errorf("GetByID() after reclaim = %v, %v; want nothing", r, err)
+		}
+	})
+}
+
+func TestRetiredNamesAreKept(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	resp, err := s.ReserveServerName(ctx, webPayload)
+	if err != nil {
+		t.Fatalf("ReserveServerName() error = %v", err)
+	}
+	if err := s.CommitReservation(ctx, resp.ReservationID, nil); err != nil {
+		t.Fatalf("CommitReservation() error = %v", err)
+	}
+	if err := s.RetireReservation(ctx, resp.ReservationID); err != nil {
+		t.Fatalf("RetireReservation() error = %v", err)
+	}
+
+	for name, action := range map[string]func(context.Context, string) error{
+		"delete":       s.DeleteReservation,
+		"release":      s.ReleaseReservation,
+		"recommission": s.RecommissionReservation,
+	} {
+		if err := action(ctx, resp.ReservationID); errorCode(err) != "invalid_transition" {
+			t.Errorf("%s of a retired name: error = %v, want invalid_transition", name, err)
+		}
+	}
+
+	// A retired number is never handed out again
+	next, err := s.ReserveServerName(ctx, webPayload)
+	if err != nil {
+		t.Fatalf("ReserveServerName() error = %v", err)
+	}
+	if next.ServerName == resp.ServerName {
+		t.Errorf("ReserveServerName() reused retired name %s", resp.ServerName)
+	}
+}
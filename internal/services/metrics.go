@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// capacityScrapeTimeout bounds the prefix usage query run on each scrape
+const capacityScrapeTimeout = 10 * time.Second
+
+var (
+	sequenceCapacityDesc = prometheus.NewDesc(
+		"server_names_sequence_capacity",
+		"Sequence numbers available to a name prefix under its naming scheme.",
+		[]string{"prefix", "overflow_policy"}, nil,
+	)
+	sequenceUsedDesc = prometheus.NewDesc(
+		"server_names_sequence_used",
+		"Sequence numbers of a name prefix held by reservations.",
+		[]string{"prefix", "overflow_policy"}, nil,
+	)
+	sequenceRemainingDesc = prometheus.NewDesc(
+		"server_names_sequence_remaining",
+		"Sequence numbers of a name prefix still free.",
+		[]string{"prefix", "overflow_policy"}, nil,
+	)
+)
+
+// CapacityCollector reports the remaining sequence capacity of every name
+// prefix. The usage is read from the database on each scrape.
+type CapacityCollector struct {
+	nameService *NameGeneratorService
+	logger      *utils.Logger
+}
+
+// NewCapacityCollector creates a collector for the prefixes of nameService
+func NewCapacityCollector(nameService *NameGeneratorService, logger *utils.Logger) *CapacityCollector {
+	return &CapacityCollector{
+		nameService: nameService,
+		logger:      logger,
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *CapacityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sequenceCapacityDesc
+	ch <- sequenceUsedDesc
+	ch <- sequenceRemainingDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *CapacityCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), capacityScrapeTimeout)
+	defer cancel()
+
+	prefixes, err := c.nameService.getPrefixStats(ctx)
+	if err != nil {
+		c.logger.Error("Failed to collect sequence capacity", "error", err)
+		ch <- prometheus.NewInvalidMetric(sequenceRemainingDesc, err)
+		return
+	}
+
+	collectPrefixes(ch, prefixes)
+}
+
+// prefixLabels identifies the series of one prefix
+type prefixLabels struct {
+	prefix         string
+	overflowPolicy string
+}
+
+// collectPrefixes sends the capacity metrics of each prefix. Stats that
+// render to the same prefix and policy share one range of names, so they are
+// merged: Prometheus fails the whole scrape when a label set repeats.
+func collectPrefixes(ch chan<- prometheus.Metric, prefixes []PrefixStat) {
+	merged := make(map[prefixLabels]*PrefixStat)
+	var order []prefixLabels
+	for _, p := range prefixes {
+		labels := prefixLabels{p.Prefix, p.OverflowPolicy}
+		m, ok := merged[labels]
+		if !ok {
+			stat := p
+			merged[labels] = &stat
+			order = append(order, labels)
+			continue
+		}
+		m.Used += p.Used
+		if p.Capacity > m.Capacity {
+			m.Capacity = p.Capacity
+		}
+	}
+
+	for _, labels := range order {
+		p := merged[labels]
+		remaining := p.Capacity - p.Used
+		if remaining < 0 {
+			remaining = 0
+		}
+		ch <- prometheus.MustNewConstMetric(sequenceCapacityDesc, prometheus.GaugeValue, float64(p.Capacity), p.Prefix, p.OverflowPolicy)
+		ch <- prometheus.MustNewConstMetric(sequenceUsedDesc, prometheus.GaugeValue, float64(p.Used), p.Prefix, p.OverflowPolicy)
+		ch <- prometheus.MustNewConstMetric(sequenceRemainingDesc, prometheus.GaugeValue, float64(remaining), p.Prefix, p.OverflowPolicy)
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// staticPrefixes collects fixed prefix stats
+type staticPrefixes []PrefixStat
+
+func (s staticPrefixes) Describe(ch chan<- *prometheus.Desc) {
+	(&CapacityCollector{}).Describe(ch)
+}
+
+func (s staticPrefixes) Collect(ch chan<- prometheus.Metric) {
+	collectPrefixes(ch, s)
+}
+
+func TestCollectPrefixesMergesRepeatedPrefixes(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(staticPrefixes{
+		// A key counted under an older scheme and under the current one
+		{Prefix: "ABCVAWEU1PWB", Used: 600, Capacity: 999, OverflowPolicy: "reject"},
+		{Prefix: "ABCVAWEU1PWB", Used: 300, Capacity: 999, OverflowPolicy: "reject"},
+		{Prefix: "ABCVAWEU1PWB", Used: 5, Capacity: 999 + 26*36*36, OverflowPolicy: "alphabet"},
+		{Prefix: "abc-weu1-p-db-", Used: 1200, Capacity: 999, OverflowPolicy: "reject"},
+	})
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	got := make(map[string]float64)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			key := family.GetName() + " " + labels["prefix"] + " " + labels["overflow_policy"]
+			got[key] = m.GetGauge().GetValue()
+		}
+	}
+
+	want := map[string]float64{
+		"server_names_sequence_used ABCVAWEU1PWB reject":        900,
+		"server_names_sequence_remaining ABCVAWEU1PWB reject":   99,
+		"server_names_sequence_capacity ABCVAWEU1PWB alphabet":  999 + 26*36*36,
+		"server_names_sequence_used ABCVAWEU1PWB alphabet":      5,
+		"server_names_sequence_remaining abc-weu1-p-db- reject": 0,
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %v", key, got[key], value)
+		}
+	}
+	if len(got) != 9 {
+		t.Errorf("Gather() returned %d series, want 9", len(got))
+	}
+}
@@ -4,35 +4,33 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/bilbothegreedy/server-name-generator/internal/db"
 	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/metrics"
 	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
 	"github.com/bilbothegreedy/server-name-generator/internal/utils"
 	"github.com/google/uuid"
 )
 
-// Character limits for each field
-const (
-	UnitCodeMaxLen    = 3
-	TypeMaxLen        = 1
-	ProviderMaxLen    = 1
-	RegionMaxLen      = 4
-	EnvironmentMaxLen = 1
-	FunctionMaxLen    = 2
-	SequenceMaxLen    = 3
-)
-
 // Stats represents dashboard statistics
 type Stats struct {
-	TotalReservations  int                   `json:"totalReservations"`
-	CommittedCount     int                   `json:"committedCount"`
-	ReservedCount      int                   `json:"reservedCount"`
-	RecentReservations []*models.Reservation `json:"recentReservations"`
-	TopEnvironments    []EnvStat             `json:"topEnvironments"`
-	TopRegions         []RegionStat          `json:"topRegions"`
-	DailyActivity      []DailyStat           `json:"dailyActivity"`
+	TotalReservations   int                   `json:"totalReservations"`
+	CommittedCount      int                   `json:"committedCount"`
+	ReservedCount       int                   `json:"reservedCount"`
+	ExpiredCount        int                   `json:"expiredCount"`
+	DecommissionedCount int                   `json:"decommissionedCount"`
+	RetiredCount        int                   `json:"retiredCount"`
+	RecentReservations  []*models.Reservation `json:"recentReservations"`
+	TopEnvironments     []EnvStat             `json:"topEnvironments"`
+	TopRegions          []RegionStat          `json:"topRegions"`
+	DailyActivity       []DailyStat           `json:"dailyActivity"`
+	PrefixUsage         []PrefixStat          `json:"prefixUsage"`
 }
 
 // EnvStat represents environment usage statistics
@@ -54,25 +52,56 @@ type DailyStat struct {
 	Committed int    `json:"committed"`
 }
 
+// PrefixStat represents how full the sequence range of a name prefix is
+type PrefixStat struct {
+	Prefix         string  `json:"prefix"`
+	Used           int     `json:"used"`
+	Highest        int     `json:"highest"`
+	Capacity       int     `json:"capacity"`
+	PercentFull    float64 `json:"percentFull"`
+	OverflowPolicy string  `json:"overflowPolicy"`
+}
+
 // NameGeneratorService handles the business logic for server name generation
 type NameGeneratorService struct {
 	db               *sql.DB
+	txRunner         *db.TxRunner
 	sequenceModel    *models.SequenceModel
 	reservationModel *models.ReservationModel
+	schemeModel      *models.NamingSchemeModel
+	catalogModel     *models.CatalogModel
+	eventModel       *models.EventModel
+	webhookModel     *models.WebhookModel
+	outboxModel      *models.OutboxModel // nil when no outbox sinks are configured
+	lifetimes        ReservationLifetimes
 	logger           *utils.Logger
 }
 
 // NewNameGeneratorService creates a new name generator service
 func NewNameGeneratorService(
 	db *sql.DB,
+	txRunner *db.TxRunner,
 	sequenceModel *models.SequenceModel,
 	reservationModel *models.ReservationModel,
+	schemeModel *models.NamingSchemeModel,
+	catalogModel *models.CatalogModel,
+	eventModel *models.EventModel,
+	webhookModel *models.WebhookModel,
+	outboxModel *models.OutboxModel,
+	lifetimes ReservationLifetimes,
 	logger *utils.Logger,
 ) *NameGeneratorService {
 	return &NameGeneratorService{
 		db:               db,
+		txRunner:         txRunner,
 		sequenceModel:    sequenceModel,
 		reservationModel: reservationModel,
+		schemeModel:      schemeModel,
+		catalogModel:     catalogModel,
+		eventModel:       eventModel,
+		webhookModel:     webhookModel,
+		outboxModel:      outboxModel,
+		lifetimes:        lifetimes,
 		logger:           logger,
 	}
 }
@@ -94,245 +123,642 @@ func (s *NameGeneratorService) NormalizeField(value string, maxLen int, defaultV
 	return value
 }
 
-// GenerateServerName creates a server name from the provided parameters and sequence number
-func (s *NameGeneratorService) GenerateServerName(params models.ReservationPayload, sequenceNum int) string {
-	// Normalize each field to its max length
-	unitCode := s.NormalizeField(params.UnitCode, UnitCodeMaxLen, "SRV")
-	hostType := s.NormalizeField(params.Type, TypeMaxLen, "V")          // Default V for VM
-	provider := s.NormalizeField(params.Provider, ProviderMaxLen, "X")  // Default X for Mixed
-	region := s.NormalizeField(params.Region, RegionMaxLen, "GLBL")     // Default GLBL for Global
-	env := s.NormalizeField(params.Environment, EnvironmentMaxLen, "P") // Default P for Production
-	function := s.NormalizeField(params.Function, FunctionMaxLen, "SV") // Default SV for Server
-
-	// Format the sequence number with leading zeros
-	sequenceStr := fmt.Sprintf("%0*d", SequenceMaxLen, sequenceNum)
-
-	// Ensure sequence doesn't exceed maximum length
-	if len(sequenceStr) > SequenceMaxLen {
-		sequenceStr = sequenceStr[len(sequenceStr)-SequenceMaxLen:]
-	}
-
-	// Combine all parts with no separators to form a fixed-width name
-	return fmt.Sprintf("%s%s%s%s%s%s%s",
-		unitCode,
-		hostType,
-		provider,
-		region,
-		env,
-		function,
-		sequenceStr,
-	)
+// ResolveScheme returns the naming scheme selected by name and version. An
+// empty name selects the default scheme, falling back to the built-in compact
+// scheme when the database does not define one.
+func (s *NameGeneratorService) ResolveScheme(ctx context.Context, name string, version int) (*models.NamingScheme, error) {
+	if name == "" {
+		scheme, err := s.schemeModel.GetDefault(ctx)
+		if err != nil {
+			return nil, errors.NewDatabaseError("Failed to load default naming scheme", err)
+		}
+		if scheme == nil {
+			return models.DefaultNamingScheme(), nil
+		}
+		return scheme, nil
+	}
+
+	scheme, err := s.schemeModel.GetByName(ctx, name, version)
+	if err != nil {
+		return nil, errors.NewDatabaseError("Failed to load naming scheme", err)
+	}
+	if scheme == nil {
+		if version > 0 {
+			return nil, errors.NewValidationError(fmt.Sprintf("Unknown naming scheme %s version %d", name, version), nil)
+		}
+		return nil, errors.NewValidationError(fmt.Sprintf("Unknown naming scheme %s", name), nil)
+	}
+
+	return scheme, nil
 }
 
-// GetNameBasePattern creates a pattern for finding similar server names
-func (s *NameGeneratorService) GetNameBasePattern(params models.ReservationPayload) string {
-	// Normalize each field to its max length
-	unitCode := s.NormalizeField(params.UnitCode, UnitCodeMaxLen, "SRV")
-	hostType := s.NormalizeField(params.Type, TypeMaxLen, "V")
-	provider := s.NormalizeField(params.Provider, ProviderMaxLen, "X")
-	region := s.NormalizeField(params.Region, RegionMaxLen, "GLBL")
-	env := s.NormalizeField(params.Environment, EnvironmentMaxLen, "P")
-	function := s.NormalizeField(params.Function, FunctionMaxLen, "SV")
-
-	// Create pattern without sequence number
-	return fmt.Sprintf("%s%s%s%s%s%s",
-		unitCode,
-		hostType,
-		provider,
-		region,
-		env,
-		function,
-	)
+// NormalizePayload applies the scheme's defaults, length limits and character
+// sets to each segment. Values longer than the segment are rejected rather
+// than truncated. Values are stored in upper case; the scheme's case is only
+// applied when the name is rendered. Fields the scheme does not use are left
+// empty.
+func (s *NameGeneratorService) NormalizePayload(scheme *models.NamingScheme, params models.ReservationPayload) (models.ReservationPayload, error) {
+	normalized := models.ReservationPayload{
+		Scheme:        scheme.Name,
+		SchemeVersion: scheme.Version,
+	}
+
+	for _, seg := range scheme.Segments {
+		raw := params.SegmentValue(seg.Field)
+		if len(raw) > seg.Length {
+			return normalized, errors.NewValidationError(
+				fmt.Sprintf("%s must be at most %d characters long", seg.Field, seg.Length), nil)
+		}
+
+		value := s.NormalizeField(raw, seg.Length, strings.ToUpper(seg.Default))
+		if !matchesCharset(value, seg.Charset) {
+			return normalized, errors.NewValidationError(
+				fmt.Sprintf("%s must contain only %s characters", seg.Field, charsetName(seg.Charset)), nil)
+		}
+		normalized.SetSegmentValue(seg.Field, value)
+	}
+
+	return normalized, nil
 }
 
-func (s *NameGeneratorService) ReserveServerName(ctx context.Context, params models.ReservationPayload) (*models.ReservationResponse, error) {
-	// Start a transaction
-	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
-	if err != nil {
-		return nil, errors.NewDatabaseError("Failed to start transaction", err)
+// ValidateCatalogs checks every segment value used by the scheme against its
+// managed catalog. Segments without catalog entries accept any value.
+func (s *NameGeneratorService) ValidateCatalogs(ctx context.Context, scheme *models.NamingScheme, params models.ReservationPayload) error {
+	for _, seg := range scheme.Segments {
+		values, err := s.catalogModel.GetBySegment(ctx, seg.Field)
+		if err != nil {
+			return errors.NewDatabaseError("Failed to load segment catalog", err)
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		code := params.SegmentValue(seg.Field)
+		var valid []string
+		var found *models.CatalogValue
+		for _, v := range values {
+			if v.Code == code {
+				found = v
+			}
+			if !v.Deprecated {
+				valid = append(valid, v.Code)
+			}
+		}
+
+		if found != nil && !found.Deprecated {
+			continue
+		}
+
+		message := fmt.Sprintf("%s %s is not a known code", seg.Field, code)
+		if found != nil {
+			message = fmt.Sprintf("%s %s is deprecated", seg.Field, code)
+		}
+		return errors.NewValidationError(message, nil).
+			WithDetail("valid options: " + strings.Join(valid, ", ")).
+			WithCode("invalid_segment_value")
 	}
-	defer tx.Rollback() // Will be ignored if transaction is committed
 
-	// Get the base pattern for the name
-	nameBasePattern := s.GetNameBasePattern(params)
+	return nil
+}
+
+// matchesCharset reports whether every character of value belongs to the charset
+func matchesCharset(value, charset string) bool {
+	for _, c := range value {
+		isAlpha := c >= 'A' && c <= 'Z'
+		isDigit := c >= '0' && c <= '9'
+		switch charset {
+		case models.CharsetAlpha:
+			if !isAlpha {
+				return false
+			}
+		case models.CharsetNumeric:
+			if !isDigit {
+				return false
+			}
+		default:
+			if !isAlpha && !isDigit {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// charsetName returns the human readable name of a segment charset
+func charsetName(charset string) string {
+	if charset == "" {
+		return models.CharsetAlphanumeric
+	}
+	return charset
+}
+
+// GenerateServerName creates a server name from normalized parameters and a
+// sequence number that fits the scheme's capacity
+func (s *NameGeneratorService) GenerateServerName(scheme *models.NamingScheme, params models.ReservationPayload, sequenceNum int) string {
+	sequenceStr := FormatSequence(sequenceNum, scheme.SequenceWidth)
+	if scheme.Case == models.CaseLower {
+		sequenceStr = strings.ToLower(sequenceStr)
+	}
+
+	return s.GetNameBasePattern(scheme, params) + sequenceStr
+}
+
+// GetNameBasePattern creates the name prefix shared by all sequence numbers
+// for the given normalized parameters, including the trailing separator
+func (s *NameGeneratorService) GetNameBasePattern(scheme *models.NamingScheme, params models.ReservationPayload) string {
+	var b strings.Builder
+	for _, seg := range scheme.Segments {
+		b.WriteString(params.SegmentValue(seg.Field))
+		b.WriteString(scheme.Separator)
+	}
+
+	if scheme.Case == models.CaseLower {
+		return strings.ToLower(b.String())
+	}
+	return b.String()
+}
+
+// preparedReservation holds a reservation request that passed scheme and
+// catalog validation and is ready to be allocated inside a transaction
+type preparedReservation struct {
+	scheme    *models.NamingScheme
+	params    models.ReservationPayload
+	details   models.ReservationDetails
+	tags      map[string]string
+	expiresAt *time.Time
+}
 
-	// Find the latest sequence number for similar names
-	latestSequence, err := s.reservationModel.FindLatestSequenceNumber(ctx, tx, nameBasePattern)
+// prepareReservation resolves the naming scheme and validates the segment
+// values of a reservation request before touching the database
+func (s *NameGeneratorService) prepareReservation(ctx context.Context, params models.ReservationPayload) (*preparedReservation, error) {
+	scheme, err := s.ResolveScheme(ctx, params.Scheme, params.SchemeVersion)
 	if err != nil {
-		return nil, errors.NewDatabaseError("Failed to find latest sequence number", err)
+		return nil, err
 	}
 
-	// Increment sequence number
-	sequenceNum := latestSequence + 1
+	normalized, err := s.NormalizePayload(scheme, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateCatalogs(ctx, scheme, normalized); err != nil {
+		return nil, err
+	}
+
+	if err := models.ValidateTags(params.Tags); err != nil {
+		return nil, errors.NewValidationError(err.Error(), err)
+	}
+
+	return &preparedReservation{
+		scheme:    scheme,
+		params:    normalized,
+		details:   params.ReservationDetails,
+		tags:      params.Tags,
+		expiresAt: s.expiresAt(params.TTLSeconds),
+	}, nil
+}
+
+// expiresAt returns when a reservation made now with the given TTL expires.
+// A TTL of 0 selects the configured default; nil means it never expires.
+func (s *NameGeneratorService) expiresAt(ttlSeconds int) *time.Time {
+	ttl := s.lifetimes.TTL
+	if ttlSeconds > 0 {
+		ttl = time.Duration(ttlSeconds) * time.Second
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	expiresAt := time.Now().UTC().Add(ttl)
+	return &expiresAt
+}
+
+// reserve allocates a sequence number and creates the reservation inside tx
+func (s *NameGeneratorService) reserve(ctx context.Context, tx *sql.Tx, p *preparedReservation) (*models.Reservation, error) {
+	scheme, normalized := p.scheme, p.params
+	key := models.SequenceKeyFor(normalized)
+
+	// Allocate the next number according to the key's settings
+	sequenceNum, err := s.allocateSequence(ctx, tx, scheme, key, s.GetNameBasePattern(scheme, normalized))
+	if err != nil {
+		return nil, err
+	}
 
 	// Generate server name
-	serverName := s.GenerateServerName(params, sequenceNum)
+	serverName := s.GenerateServerName(scheme, normalized, sequenceNum)
 
-	// Check if server name is unique (committed reservations)
-	isUnique, err := s.reservationModel.IsServerNameUnique(ctx, tx, serverName)
+	return s.createReservation(ctx, tx, p, sequenceNum, serverName)
+}
+
+// createReservation checks that serverName is not held by any reservation
+// and stores a new reservation for it inside tx, owned by the principal
+// making the request
+func (s *NameGeneratorService) createReservation(ctx context.Context, tx *sql.Tx, p *preparedReservation, sequenceNum int, serverName string) (*models.Reservation, error) {
+	normalized := p.params
+
+	// Server names are unique across reservations of every status
+	taken, err := s.reservationModel.IsServerNameTaken(ctx, tx, serverName)
 	if err != nil {
 		return nil, errors.NewDatabaseError("Failed to check server name uniqueness", err)
 	}
 
-	if !isUnique {
+	if taken {
 		return nil, errors.NewConflictError(fmt.Sprintf("Server name %s is already in use", serverName))
 	}
 
 	// Create reservation
 	now := time.Now().UTC()
-	reservationID := uuid.New().String()
-
-	// Normalize fields for storage
 	reservation := &models.Reservation{
-		ID:          reservationID,
+		ID:          uuid.New().String(),
 		ServerName:  serverName,
-		UnitCode:    s.NormalizeField(params.UnitCode, UnitCodeMaxLen, "SRV"),
-		Type:        s.NormalizeField(params.Type, TypeMaxLen, "V"),
-		Provider:    s.NormalizeField(params.Provider, ProviderMaxLen, "X"),
-		Region:      s.NormalizeField(params.Region, RegionMaxLen, "GLBL"),
-		Environment: s.NormalizeField(params.Environment, EnvironmentMaxLen, "P"),
-		Function:    s.NormalizeField(params.Function, FunctionMaxLen, "SV"),
+		UnitCode:    normalized.UnitCode,
+		Type:        normalized.Type,
+		Provider:    normalized.Provider,
+		Region:      normalized.Region,
+		Environment: normalized.Environment,
+		Function:    normalized.Function,
 		SequenceNum: sequenceNum,
 		Status:      models.StatusReserved,
+		SchemeID:    p.scheme.ID,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		ExpiresAt:   p.expiresAt,
+
+		ReservationDetails: p.details,
+		Tags:               p.tags,
+	}
+	if principal, ok := utils.PrincipalFromContext(ctx); ok {
+		reservation.CreatedBy = principal.UserID
+		reservation.APIKeyID = principal.APIKeyID
 	}
 
 	if err := s.reservationModel.Create(ctx, tx, reservation); err != nil {
+		if db.IsUniqueViolation(err) {
+			return nil, errors.NewConflictError(fmt.Sprintf("Server name %s is already in use", serverName))
+		}
 		return nil, errors.NewDatabaseError("Failed to create reservation", err)
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return nil, errors.NewDatabaseError("Failed to commit transaction", err)
+	if err := s.recordEvent(ctx, tx, models.EventCreated, nil, reservation); err != nil {
+		return nil, errors.NewDatabaseError("Failed to record reservation event", err)
 	}
 
-	return &models.ReservationResponse{
-		ReservationID: reservationID,
-		ServerName:    serverName,
-	}, nil
+	return reservation, nil
 }
 
-// CommitReservation commits a server name reservation
-func (s *NameGeneratorService) CommitReservation(ctx context.Context, reservationID string) error {
-	// Start a transaction
-	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+// ClaimServerName reserves a caller-chosen server name. The name must conform
+// to the selected naming scheme and its catalogs. The prefix counter is moved
+// past the claimed number so generated names never collide with it.
+func (s *NameGeneratorService) ClaimServerName(ctx context.Context, params models.ExplicitReservationPayload) (_ *models.ReservationResponse, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.ClaimServerName")
+	defer func() { tracing.End(span, err) }()
+	defer func() { metrics.RecordOperation(metrics.OperationReserve, err) }()
+
+	scheme, err := s.ResolveScheme(ctx, params.Scheme, params.SchemeVersion)
 	if err != nil {
-		return fmt.Errorf("failed to start transaction: %w", err)
+		return nil, err
+	}
+
+	parsed, sequenceNum, ok := s.ParseServerName(scheme, params.ServerName)
+	if !ok {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("Server name %s does not conform to naming scheme %s version %d",
+				params.ServerName, scheme.Name, scheme.Version), nil).
+			WithCode("invalid_server_name")
 	}
-	defer tx.Rollback() // Will be ignored if transaction is committed
 
-	// Check if reservation exists
-	reservation, err := s.reservationModel.GetByID(ctx, reservationID)
+	normalized, err := s.NormalizePayload(scheme, parsed)
 	if err != nil {
-		return fmt.Errorf("failed to get reservation: %w", err)
+		return nil, err
 	}
 
-	if reservation == nil {
-		return fmt.Errorf("reservation with ID %s not found", reservationID)
+	if err := s.ValidateCatalogs(ctx, scheme, normalized); err != nil {
+		return nil, err
 	}
 
-	if reservation.Status == models.StatusCommitted {
-		return fmt.Errorf("reservation is already committed")
+	if err := models.ValidateTags(params.Tags); err != nil {
+		return nil, errors.NewValidationError(err.Error(), err)
 	}
 
-	// Update reservation status to committed
-	if err := s.reservationModel.UpdateStatus(ctx, tx, reservationID, models.StatusCommitted); err != nil {
-		return fmt.Errorf("failed to update reservation status: %w", err)
+	if sequenceNum < 1 {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("Server name %s has sequence 0, sequences start at 1", params.ServerName), nil).
+			WithCode("sequence_out_of_range")
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	key := models.SequenceKeyFor(normalized)
+	serverName := s.GenerateServerName(scheme, normalized, sequenceNum)
+	prepared := &preparedReservation{
+		scheme:    scheme,
+		params:    normalized,
+		details:   params.ReservationDetails,
+		tags:      params.Tags,
+		expiresAt: s.expiresAt(params.TTLSeconds),
 	}
+	var reservation *models.Reservation
 
-	return nil
+	err = s.txRunner.Run(ctx, "ClaimServerName", func(tx *sql.Tx) error {
+		// The number must fit the prefix's range, or moving the counter
+		// past it would exhaust the prefix
+		settings, err := s.sequenceModel.GetSettings(ctx, tx, key)
+		if err != nil {
+			return errors.NewDatabaseError("Failed to get sequence settings", err)
+		}
+		if capacity := SequenceCapacity(scheme.SequenceWidth, settings.OverflowPolicy); sequenceNum > capacity {
+			return errors.NewValidationError(
+				fmt.Sprintf("Sequence of server name %s is outside the %d numbers available under the %s overflow policy",
+					serverName, capacity, settings.OverflowPolicy), nil).
+				WithCode("sequence_out_of_range")
+		}
+
+		reservation, err = s.createReservation(ctx, tx, prepared, sequenceNum, serverName)
+		if err != nil {
+			return err
+		}
+
+		if err := s.sequenceModel.AdvanceSequenceNumber(ctx, tx, key, sequenceNum); err != nil {
+			return errors.NewDatabaseError("Failed to update sequence", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, transactionError(err, "Failed to claim server name")
+	}
+
+	return &models.ReservationResponse{
+		ReservationID: reservation.ID,
+		ServerName:    reservation.ServerName,
+		ExpiresAt:     reservation.ExpiresAt,
+	}, nil
 }
 
-// GetAllReservations retrieves all reservations
-func (s *NameGeneratorService) GetAllReservations(ctx context.Context) ([]*models.Reservation, error) {
-	query := `
-		SELECT id, server_name, unit_code, type, provider, region, environment, function,
-			   sequence_num, status, created_at, updated_at
-		FROM reservations
-		ORDER BY created_at DESC
-	`
+// ReserveServerName reserves the next available server name for the given parameters
+func (s *NameGeneratorService) ReserveServerName(ctx context.Context, params models.ReservationPayload) (_ *models.ReservationResponse, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.ReserveServerName")
+	defer func() { tracing.End(span, err) }()
+	defer func() { metrics.RecordOperation(metrics.OperationReserve, err) }()
 
-	rows, err := s.db.QueryContext(ctx, query)
+	prepared, err := s.prepareReservation(ctx, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query reservations: %w", err)
-	}
-	defer rows.Close()
-
-	var reservations []*models.Reservation
-	for rows.Next() {
-		r := &models.Reservation{}
-		err := rows.Scan(
-			&r.ID,
-			&r.ServerName,
-			&r.UnitCode,
-			&r.Type,
-			&r.Provider,
-			&r.Region,
-			&r.Environment,
-			&r.Function,
-			&r.SequenceNum,
-			&r.Status,
-			&r.CreatedAt,
-			&r.UpdatedAt,
-		)
+		return nil, err
+	}
+
+	var reservation *models.Reservation
+	err = s.txRunner.Run(ctx, "ReserveServerName", func(tx *sql.Tx) error {
+		reservation, err = s.reserve(ctx, tx, prepared)
+		return err
+	})
+	if err != nil {
+		return nil, transactionError(err, "Failed to reserve server name")
+	}
+
+	return &models.ReservationResponse{
+		ReservationID: reservation.ID,
+		ServerName:    reservation.ServerName,
+		ExpiresAt:     reservation.ExpiresAt,
+	}, nil
+}
+
+// ReserveServerNames reserves a name for every payload in a single
+// transaction. Either all names are reserved or none are.
+func (s *NameGeneratorService) ReserveServerNames(ctx context.Context, payloads []models.ReservationPayload) (_ []*models.ReservationResponse, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.ReserveServerNames")
+	defer func() { tracing.End(span, err) }()
+	defer func() { metrics.RecordOperation(metrics.OperationReserve, err) }()
+
+	prepared := make([]*preparedReservation, len(payloads))
+	for i, params := range payloads {
+		p, err := s.prepareReservation(ctx, params)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+			// Point at the offending entry without losing the error's detail
+			if appErr, ok := err.(*errors.AppError); ok {
+				appErr.Message = fmt.Sprintf("Reservation %d: %s", i, appErr.Message)
+			}
+			return nil, err
 		}
-		reservations = append(reservations, r)
+		prepared[i] = p
+	}
+
+	var responses []*models.ReservationResponse
+	err = s.txRunner.Run(ctx, "ReserveServerNames", func(tx *sql.Tx) error {
+		// Start over on each attempt so a retried transaction
+		// does not report names from a rolled-back one
+		responses = make([]*models.ReservationResponse, 0, len(prepared))
+		for _, p := range prepared {
+			reservation, err := s.reserve(ctx, tx, p)
+			if err != nil {
+				return err
+			}
+			responses = append(responses, &models.ReservationResponse{
+				ReservationID: reservation.ID,
+				ServerName:    reservation.ServerName,
+				ExpiresAt:     reservation.ExpiresAt,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, transactionError(err, "Failed to reserve server names")
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating reservations: %w", err)
+	return responses, nil
+}
+
+// PreviewServerName returns the name a reservation with the given parameters
+// would get right now. It only reads, in a read-only transaction, so it
+// neither consumes a number nor locks the sequence counter; a concurrent
+// reservation may still take the previewed name first.
+func (s *NameGeneratorService) PreviewServerName(ctx context.Context, params models.ReservationPayload) (_ *models.PreviewResponse, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.PreviewServerName")
+	defer func() { tracing.End(span, err) }()
+
+	prepared, err := s.prepareReservation(ctx, params)
+	if err != nil {
+		return nil, err
 	}
 
-	return reservations, nil
-}
+	scheme, normalized := prepared.scheme, prepared.params
+	preview := &models.PreviewResponse{
+		Scheme:        scheme.Name,
+		SchemeVersion: scheme.Version,
+		Fields:        normalized,
+	}
+	preview.Fields.Scheme = ""
+	preview.Fields.SchemeVersion = 0
 
-// DeleteReservation deletes a reservation by ID (only if not committed)
-func (s *NameGeneratorService) DeleteReservation(ctx context.Context, id string) error {
-	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
-		return fmt.Errorf("failed to start transaction: %w", err)
+		return nil, errors.NewDatabaseError("Failed to preview server name", err)
 	}
 	defer tx.Rollback()
 
-	// First check if the reservation exists
+	key := models.SequenceKeyFor(normalized)
+	sequenceNum, err := s.peekSequence(ctx, tx, scheme, key, s.GetNameBasePattern(scheme, normalized))
+	if err != nil {
+		return nil, err
+	}
+
+	serverName := s.GenerateServerName(scheme, normalized, sequenceNum)
+	isUnique, err := s.reservationModel.IsServerNameUnique(ctx, tx, serverName)
+	if err != nil {
+		return nil, errors.NewDatabaseError("Failed to check server name uniqueness", err)
+	}
+	if !isUnique {
+		return nil, errors.NewConflictError(fmt.Sprintf("Server name %s is already in use", serverName))
+	}
+
+	preview.ServerName = serverName
+	preview.SequenceNum = sequenceNum
+	return preview, nil
+}
+
+// transactionError converts a transaction runner failure into an AppError.
+// Serialization failures that survived every retry are reported as conflicts
+// so clients know the request can be retried.
+func transactionError(err error, message string) error {
+	if db.IsRetryable(err) {
+		return errors.NewConflictError("Concurrent update detected, please retry").
+			WithCode("serialization_failure")
+	}
+	if _, ok := err.(*errors.AppError); ok {
+		return err
+	}
+	return errors.NewDatabaseError(message, err)
+}
+
+// GetAllReservations retrieves all reservations
+func (s *NameGeneratorService) GetAllReservations(ctx context.Context) (_ []*models.Reservation, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.GetAllReservations")
+	defer func() { tracing.End(span, err) }()
+
+	return s.reservationModel.GetAll(ctx)
+}
+
+// GetReservation retrieves a reservation by ID
+func (s *NameGeneratorService) GetReservation(ctx context.Context, id string) (_ *models.Reservation, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.GetReservation")
+	defer func() { tracing.End(span, err) }()
+
+	notFound := errors.NewNotFoundError(fmt.Sprintf("Reservation %s not found", id))
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, notFound
+	}
+
 	reservation, err := s.reservationModel.GetByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("failed to get reservation: %w", err)
+		return nil, errors.NewDatabaseError("Failed to get reservation", err)
+	}
+	if reservation == nil {
+		return nil, notFound
 	}
+	return reservation, nil
+}
+
+// GetReservationByName retrieves the most recent reservation for a server
+// name, ignoring case
+func (s *NameGeneratorService) GetReservationByName(ctx context.Context, serverName string) (_ *models.Reservation, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.GetReservationByName")
+	defer func() { tracing.End(span, err) }()
 
+	reservation, err := s.reservationModel.GetByServerName(ctx, serverName)
+	if err != nil {
+		return nil, errors.NewDatabaseError("Failed to get reservation", err)
+	}
 	if reservation == nil {
-		return fmt.Errorf("reservation with ID %s not found", id)
+		return nil, errors.NewNotFoundError(fmt.Sprintf("No reservation found for %s", serverName))
+	}
+	return reservation, nil
+}
+
+// UpdateReservationTags applies a merge patch to a reservation's tags: a
+// value sets the tag and nil removes it. It returns the updated reservation.
+func (s *NameGeneratorService) UpdateReservationTags(ctx context.Context, id string, patch models.TagsPatchPayload) (_ *models.Reservation, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.UpdateReservationTags")
+	defer func() { tracing.End(span, err) }()
+
+	set := make(map[string]string)
+	var remove []string
+	for key, value := range patch {
+		if err := models.ValidateTagKey(key); err != nil {
+			return nil, errors.NewValidationError(err.Error(), err)
+		}
+		if value == nil {
+			remove = append(remove, key)
+		} else {
+			set[key] = *value
+		}
+	}
+	if err := models.ValidateTags(set); err != nil {
+		return nil, errors.NewValidationError(err.Error(), err)
 	}
 
-	// Check if the reservation is committed
-	if reservation.Status == "committed" {
-		return fmt.Errorf("cannot delete a committed reservation")
+	var reservation *models.Reservation
+	err = s.txRunner.Run(ctx, "UpdateReservationTags", func(tx *sql.Tx) error {
+		before, err := s.reservationModel.GetByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return errors.NewNotFoundError(fmt.Sprintf("Reservation %s not found", id))
+		}
+
+		reservation, err = s.reservationModel.UpdateTags(ctx, tx, id, set, remove)
+		if err != nil {
+			return err
+		}
+		if len(reservation.Tags) > models.MaxTags {
+			return errors.NewValidationError(fmt.Sprintf("At most %d tags are allowed", models.MaxTags), nil)
+		}
+
+		return s.recordEvent(ctx, tx, models.EventTagsUpdated, before, reservation)
+	})
+	if err != nil {
+		return nil, transactionError(err, "Failed to update reservation tags")
 	}
 
-	// Delete the reservation
-	if err := s.reservationModel.Delete(ctx, tx, id); err != nil {
-		return fmt.Errorf("failed to delete reservation: %w", err)
+	return reservation, nil
+}
+
+// FindReservations retrieves every reservation matching a filter, newest first
+func (s *NameGeneratorService) FindReservations(ctx context.Context, filter models.ReservationFilter) (_ []*models.Reservation, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.FindReservations")
+	defer func() { tracing.End(span, err) }()
+
+	return s.reservationModel.Find(ctx, normalizeFilter(filter))
+}
+
+// ListReservations retrieves one page of the reservations matching a filter
+func (s *NameGeneratorService) ListReservations(ctx context.Context, filter models.ReservationFilter, page models.PageRequest) (_ *models.ReservationPage, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.ListReservations")
+	defer func() { tracing.End(span, err) }()
+
+	if err := page.Validate(); err != nil {
+		return nil, errors.NewValidationError(err.Error(), err)
 	}
 
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	result, err := s.reservationModel.FindPage(ctx, normalizeFilter(filter), page)
+	if err != nil {
+		return nil, errors.NewDatabaseError("Failed to get reservations", err)
 	}
+	return result, nil
+}
 
-	s.logger.Info("Reservation deleted successfully", "id", id, "serverName", reservation.ServerName)
-	return nil
+// normalizeFilter upper-cases segment filters so they match stored segment values
+func normalizeFilter(filter models.ReservationFilter) models.ReservationFilter {
+	filter.UnitCode = strings.ToUpper(filter.UnitCode)
+	filter.Type = strings.ToUpper(filter.Type)
+	filter.Provider = strings.ToUpper(filter.Provider)
+	filter.Region = strings.ToUpper(filter.Region)
+	filter.Environment = strings.ToUpper(filter.Environment)
+	filter.Function = strings.ToUpper(filter.Function)
+	return filter
 }
 
 // GetStats retrieves statistics for the admin dashboard
-func (s *NameGeneratorService) GetStats(ctx context.Context) (*Stats, error) {
+func (s *NameGeneratorService) GetStats(ctx context.Context) (_ *Stats, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.GetStats")
+	defer func() { tracing.End(span, err) }()
+
 	stats := &Stats{}
 
 	// Get counts of reservations by status
@@ -340,53 +766,30 @@ func (s *NameGeneratorService) GetStats(ctx context.Context) (*Stats, error) {
 		SELECT 
 			COUNT(*) as total,
 			SUM(CASE WHEN status = 'committed' THEN 1 ELSE 0 END) as committed,
-			SUM(CASE WHEN status = 'reserved' THEN 1 ELSE 0 END) as reserved
+			SUM(CASE WHEN status = 'reserved' THEN 1 ELSE 0 END) as reserved,
+			SUM(CASE WHEN status = 'expired' THEN 1 ELSE 0 END) as expired,
+			SUM(CASE WHEN status = 'decommissioned' THEN 1 ELSE 0 END) as decommissioned,
+			SUM(CASE WHEN status = 'retired' THEN 1 ELSE 0 END) as retired
 		FROM reservations
 	`
-	err := s.db.QueryRowContext(ctx, countQuery).Scan(
+	err = s.db.QueryRowContext(ctx, countQuery).Scan(
 		&stats.TotalReservations,
 		&stats.CommittedCount,
 		&stats.ReservedCount,
+		&stats.ExpiredCount,
+		&stats.DecommissionedCount,
+		&stats.RetiredCount,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get reservation counts: %w", err)
 	}
 
 	// Get recent reservations (limited to 10)
-	recentQuery := `
-		SELECT id, server_name, unit_code, type, provider, region, environment, function,
-			   sequence_num, status, created_at, updated_at
-		FROM reservations
-		ORDER BY created_at DESC
-		LIMIT 10
-	`
-	rows, err := s.db.QueryContext(ctx, recentQuery)
+	recent, err := s.reservationModel.GetRecent(ctx, 10)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query recent reservations: %w", err)
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		r := &models.Reservation{}
-		err := rows.Scan(
-			&r.ID,
-			&r.ServerName,
-			&r.UnitCode,
-			&r.Type,
-			&r.Provider,
-			&r.Region,
-			&r.Environment,
-			&r.Function,
-			&r.SequenceNum,
-			&r.Status,
-			&r.CreatedAt,
-			&r.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan reservation: %w", err)
-		}
-		stats.RecentReservations = append(stats.RecentReservations, r)
-	}
+	stats.RecentReservations = recent
 
 	// Get top environments
 	envQuery := `
@@ -460,46 +863,81 @@ func (s *NameGeneratorService) GetStats(ctx context.Context) (*Stats, error) {
 		stats.DailyActivity = append(stats.DailyActivity, day)
 	}
 
+	// Get sequence usage per prefix
+	stats.PrefixUsage, err = s.getPrefixStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
 
-// ReleaseReservation changes a reservation status from committed to reserved
-func (s *NameGeneratorService) ReleaseReservation(ctx context.Context, id string) error {
-	// First check if the reservation exists and is committed
-	reservation, err := s.reservationModel.GetByID(ctx, id)
+// getPrefixStats reports how full each prefix is, fullest first
+func (s *NameGeneratorService) getPrefixStats(ctx context.Context) ([]PrefixStat, error) {
+	usage, err := s.reservationModel.GetPrefixUsage(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get reservation: %w", err)
-	}
+		return nil, fmt.Errorf("failed to get prefix usage: %w", err)
+	}
+
+	schemes := make(map[string]*models.NamingScheme)
+	prefixes := make([]PrefixStat, 0, len(usage))
+	for _, u := range usage {
+		scheme, ok := schemes[u.SchemeID]
+		if !ok {
+			scheme, err = s.currentScheme(ctx, u.SchemeID)
+			if err != nil {
+				return nil, err
+			}
+			schemes[u.SchemeID] = scheme
+		}
 
-	if reservation == nil {
-		return fmt.Errorf("reservation with ID %s not found", id)
-	}
+		params := models.ReservationPayload{
+			UnitCode:    u.Key.UnitCode,
+			Type:        u.Key.Type,
+			Provider:    u.Key.Provider,
+			Region:      u.Key.Region,
+			Environment: u.Key.Environment,
+			Function:    u.Key.Function,
+		}
+		capacity := SequenceCapacity(scheme.SequenceWidth, u.OverflowPolicy)
+		prefixes = append(prefixes, PrefixStat{
+			Prefix:         s.GetNameBasePattern(scheme, params),
+			Used:           u.Used,
+			Highest:        u.Highest,
+			Capacity:       capacity,
+			PercentFull:    math.Round(float64(u.Used)/float64(capacity)*1000) / 10,
+			OverflowPolicy: u.OverflowPolicy,
+		})
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool {
+		return prefixes[i].PercentFull > prefixes[j].PercentFull
+	})
+
+	return prefixes, nil
+}
 
-	if reservation.Status != "committed" {
-		return fmt.Errorf("reservation is not committed")
+// currentScheme returns the latest active version of the scheme with the
+// given ID, so a prefix is measured against the width names are generated
+// with today. Reservations without a scheme use the compact scheme.
+func (s *NameGeneratorService) currentScheme(ctx context.Context, schemeID string) (*models.NamingScheme, error) {
+	scheme := models.DefaultNamingScheme()
+	if schemeID != "" {
+		stored, err := s.schemeModel.GetByID(ctx, schemeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get naming scheme: %w", err)
+		}
+		if stored != nil {
+			scheme = stored
+		}
 	}
 
-	// Start a transaction
-	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	latest, err := s.schemeModel.GetByName(ctx, scheme.Name, 0)
 	if err != nil {
-		return fmt.Errorf("failed to start transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	// Release the reservation
-	if err := s.reservationModel.Release(ctx, tx, id); err != nil {
-		return fmt.Errorf("failed to release reservation: %w", err)
+		return nil, fmt.Errorf("failed to get naming scheme: %w", err)
 	}
-
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	if latest != nil {
+		return latest, nil
 	}
-
-	s.logger.Info("Reservation released successfully",
-		"id", id,
-		"serverName", reservation.ServerName,
-	)
-
-	return nil
+	return scheme, nil
 }
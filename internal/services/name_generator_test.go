@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/db"
+	"github.com/bilbothegreedy/server-name-generator/internal/db/dbtest"
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// newTestService returns a service backed by a freshly migrated database.
+// The calling test is skipped when no test database is configured.
+func newTestService(t *testing.T) *NameGeneratorService {
+	t.Helper()
+
+	conn := dbtest.Open(t)
+	logger := utils.NewLogger("error")
+	txRunner := db.NewTxRunner(conn, db.RetryPolicy{
+		MaxRetries: 5,
+		BaseDelay:  5 * time.Millisecond,
+		MaxDelay:   50 * time.Millisecond,
+	}, logger)
+
+	return NewNameGeneratorService(conn, txRunner,
+		models.NewSequenceModel(conn),
+		models.NewReservationModel(conn),
+		models.NewNamingSchemeModel(conn),
+		models.NewCatalogModel(conn),
+		models.NewEventModel(conn),
+		models.NewWebhookModel(conn),
+		models.NewOutboxModel(conn),
+		ReservationLifetimes{Quarantine: time.Hour},
+		logger)
+}
+
+// errorCode returns the code of an AppError, or an empty string
+func errorCode(err error) string {
+	if appErr, ok := err.(*errors.AppError); ok {
+		return appErr.Code
+	}
+	return ""
+}
+
+// webPayload is a compact scheme payload for a production web server
+var webPayload = models.ReservationPayload{
+	UnitCode: "ABC", Type: "V", Provider: "A", Region: "WEU1", Environment: "P", Function: "WB",
+}
+
+func TestReserveServerNameCountsPerPrefix(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	dev := webPayload
+	dev.Environment = "D"
+
+	want := []struct {
+		params models.ReservationPayload
+		name   string
+	}{
+		{webPayload, "ABCVAWEU1PWB001"},
+		{webPayload, "ABCVAWEU1PWB002"},
+		{dev, "ABCVAWEU1DWB001"},
+		{webPayload, "ABCVAWEU1PWB003"},
+		{dev, "ABCVAWEU1DWB002"},
+	}
+	for _, w := range want {
+		resp, err := s.ReserveServerName(ctx, w.params)
+		if err != nil {
+			t.Fatalf("ReserveServerName(%s) error = %v", w.name, err)
+		}
+		if resp.ServerName != w.name {
+			t.Errorf("ReserveServerName() = %q, want %q", resp.ServerName, w.name)
+		}
+	}
+
+	sequences, err := s.GetSequences(ctx)
+	if err != nil {
+		t.Fatalf("GetSequences() error = %v", err)
+	}
+	counters := make(map[string]int)
+	for _, seq := range sequences {
+		counters[seq.Environment] = seq.CurrentValue
+	}
+	if counters["P"] != 3 || counters["D"] != 2 {
+		t.Errorf("sequence counters = %v, want P:3 D:2", counters)
+	}
+}
+
+func TestReservationTags(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	params := webPayload
+	params.Tags = map[string]string{"app": "billing", "env": "prod"}
+	resp, err := s.ReserveServerName(ctx, params)
+	if err != nil {
+		t.Fatalf("ReserveServerName() error = %v", err)
+	}
+
+	// Tags given on commit are merged over the reserved ones
+	if err := s.CommitReservation(ctx, resp.ReservationID, map[string]string{"env": "staging", "owner": "ops"}); err != nil {
+		t.Fatalf("CommitReservation() error = %v", err)
+	}
+
+	gold := "gold"
+	updated, err := s.UpdateReservationTags(ctx, resp.ReservationID, models.TagsPatchPayload{
+		"owner": nil,
+		"tier":  &gold,
+	})
+	if err != nil {
+		t.Fatalf("UpdateReservationTags() error = %v", err)
+	}
+	want := map[string]string{"app": "billing", "env": "staging", "tier": "gold"}
+	if !reflect.DeepEqual(updated.Tags, want) {
+		t.Errorf("tags = %v, want %v", updated.Tags, want)
+	}
+
+	if _, err := s.UpdateReservationTags(ctx, resp.ReservationID, models.TagsPatchPayload{"bad key": &gold}); err == nil {
+		t.Error("UpdateReservationTags() accepted an invalid key")
+	}
+
+	_, err = s.UpdateReservationTags(ctx, "00000000-0000-4000-8000-000000000000", models.TagsPatchPayload{"tier": &gold})
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Type != errors.ErrorTypeNotFound {
+		t.Errorf("UpdateReservationTags() on a missing reservation error = %v, want not found", err)
+	}
+}
+
+func TestPrefixStatsCountEachSequenceKeyOnce(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := s.ReserveServerName(ctx, webPayload); err != nil {
+		t.Fatalf("ReserveServerName() error = %v", err)
+	}
+
+	// A new compact version shares the sequence but renders it differently
+	v2 := models.DefaultNamingScheme()
+	created, err := s.CreateNamingScheme(ctx, models.NamingSchemePayload{
+		Name:          v2.Name,
+		Separator:     "-",
+		Segments:      v2.Segments,
+		SequenceWidth: v2.SequenceWidth,
+	})
+	if err != nil {
+		t.Fatalf("CreateNamingScheme() error = %v", err)
+	}
+	params := webPayload
+	params.Scheme = created.Name
+	if _, err := s.ReserveServerName(ctx, params); err != nil {
+		t.Fatalf("ReserveServerName() with version %d error = %v", created.Version, err)
+	}
+
+	prefixes, err := s.getPrefixStats(ctx)
+	if err != nil {
+		t.Fatalf("getPrefixStats() error = %v", err)
+	}
+	want := s.GetNameBasePattern(created, webPayload)
+	if len(prefixes) != 1 || prefixes[0].Prefix != want || prefixes[0].Used != 2 {
+		t.Errorf("getPrefixStats() = %+v, want %s used twice", prefixes, want)
+	}
+}
+
+func TestCreateNamingSchemeRejectsConflictingWidth(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	compact, err := s.ResolveScheme(ctx, models.DefaultSchemeName, 0)
+	if err != nil {
+		t.Fatalf("ResolveScheme() error = %v", err)
+	}
+	payload := models.NamingSchemePayload{
+		Name:          compact.Name,
+		Segments:      compact.Segments,
+		SequenceWidth: compact.SequenceWidth + 1,
+	}
+
+	_, err = s.CreateNamingScheme(ctx, payload)
+	if code := errorCode(err); code != "sequence_width_conflict" {
+		t.Fatalf("CreateNamingScheme() error = %v, want sequence_width_conflict", err)
+	}
+
+	// Once the old version is retired the wider one may take over its sequences
+	if err := s.schemeModel.Deactivate(ctx, compact.ID); err != nil {
+		t.Fatalf("Deactivate() error = %v", err)
+	}
+	if _, err := s.CreateNamingScheme(ctx, payload); err != nil {
+		t.Errorf("CreateNamingScheme() after deactivating error = %v", err)
+	}
+}
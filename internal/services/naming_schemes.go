@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
+	"github.com/google/uuid"
+)
+
+// CreateNamingScheme validates a scheme definition and stores it as the next
+// version of the named scheme
+func (s *NameGeneratorService) CreateNamingScheme(ctx context.Context, payload models.NamingSchemePayload) (_ *models.NamingScheme, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.CreateNamingScheme")
+	defer func() { tracing.End(span, err) }()
+
+	scheme := &models.NamingScheme{
+		ID:            uuid.New().String(),
+		Name:          strings.ToLower(payload.Name),
+		Description:   payload.Description,
+		Separator:     payload.Separator,
+		Case:          payload.Case,
+		Segments:      payload.Segments,
+		SequenceWidth: payload.SequenceWidth,
+		IsActive:      true,
+		IsDefault:     payload.IsDefault,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if scheme.Case == "" {
+		scheme.Case = models.CaseUpper
+	}
+
+	// Defaults are stored the same way segment values are
+	for i := range scheme.Segments {
+		scheme.Segments[i].Default = strings.ToUpper(scheme.Segments[i].Default)
+	}
+
+	if err := scheme.Validate(); err != nil {
+		return nil, errors.NewValidationError(err.Error(), err)
+	}
+
+	err = s.txRunner.Run(ctx, "CreateNamingScheme", func(tx *sql.Tx) error {
+		if err := s.checkSequenceWidth(ctx, scheme); err != nil {
+			return err
+		}
+		if err := s.schemeModel.Create(ctx, tx, scheme); err != nil {
+			return errors.NewDatabaseError("Failed to create naming scheme", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, transactionError(err, "Failed to create naming scheme")
+	}
+
+	s.logger.Info("Naming scheme created", "name", scheme.Name, "version", scheme.Version)
+	return scheme, nil
+}
+
+// checkSequenceWidth rejects a scheme whose sequence width differs from an
+// active scheme that shares its sequence counters. Counters are kept per
+// sequence key, so both schemes would draw from one counter with different
+// capacities.
+func (s *NameGeneratorService) checkSequenceWidth(ctx context.Context, scheme *models.NamingScheme) error {
+	schemes, err := s.schemeModel.GetAll(ctx)
+	if err != nil {
+		return errors.NewDatabaseError("Failed to get naming schemes", err)
+	}
+
+	for _, other := range schemes {
+		if !other.IsActive || other.SequenceWidth == scheme.SequenceWidth || !scheme.SharesSequences(other) {
+			continue
+		}
+		return errors.NewConflictError(fmt.Sprintf(
+			"Sequence width %d conflicts with width %d of %s version %d, which shares its sequences; deactivate that version first",
+			scheme.SequenceWidth, other.SequenceWidth, other.Name, other.Version)).
+			WithCode("sequence_width_conflict")
+	}
+	return nil
+}
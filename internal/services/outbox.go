@@ -0,0 +1,377 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// Outbox sink names
+const (
+	SinkStdout  = "stdout"
+	SinkFile    = "file"
+	SinkWebhook = "webhook"
+)
+
+// Outbox relay limits
+const (
+	outboxBatchSize = 100
+	outboxBaseDelay = time.Second
+	outboxMaxDelay  = 5 * time.Minute
+
+	// outboxLease is how long claimed messages are reserved for one relay
+	outboxLease = 2 * time.Minute
+)
+
+// OutboxSink receives published outbox messages. Publish may be called again
+// with a message it has already seen, so consumers must tolerate duplicates.
+type OutboxSink interface {
+	Name() string
+	Publish(ctx context.Context, msg *models.OutboxMessage) error
+}
+
+// outboxEnvelope is the published form of an outbox message
+type outboxEnvelope struct {
+	ID          int64           `json:"id"`
+	AggregateID string          `json:"aggregateId"`
+	EventType   string          `json:"eventType"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+func encodeOutboxMessage(msg *models.OutboxMessage) ([]byte, error) {
+	return json.Marshal(outboxEnvelope{
+		ID:          msg.ID,
+		AggregateID: msg.AggregateID,
+		EventType:   msg.EventType,
+		CreatedAt:   msg.CreatedAt,
+		Payload:     msg.Payload,
+	})
+}
+
+// lineSink writes each message as a JSON line
+type lineSink struct {
+	name string
+	mu   sync.Mutex
+	w    io.Writer
+	sync func() error
+}
+
+func (s *lineSink) Name() string { return s.name }
+
+func (s *lineSink) Publish(ctx context.Context, msg *models.OutboxMessage) error {
+	line, err := encodeOutboxMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if s.sync != nil {
+		return s.sync()
+	}
+	return nil
+}
+
+// NewStdoutSink returns a sink that prints messages to standard output
+func NewStdoutSink() OutboxSink {
+	return &lineSink{name: SinkStdout, w: os.Stdout}
+}
+
+// NewFileSink returns a sink that appends messages to a file, syncing after
+// each one so published messages survive a crash
+func NewFileSink(path string) (OutboxSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox file: %w", err)
+	}
+	return &lineSink{name: SinkFile, w: f, sync: f.Sync}, nil
+}
+
+// webhookSink posts messages to a single URL, signed like subscription
+// webhooks when a secret is set
+type webhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookSink returns a sink that posts messages to url
+func NewWebhookSink(url, secret string, timeout time.Duration) OutboxSink {
+	return &webhookSink{url: url, secret: secret, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *webhookSink) Name() string { return SinkWebhook }
+
+func (s *webhookSink) Publish(ctx context.Context, msg *models.OutboxMessage) error {
+	body, err := encodeOutboxMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "server-name-generator-outbox")
+	req.Header.Set(WebhookIDHeader, strconv.FormatInt(msg.ID, 10))
+	req.Header.Set(WebhookEventHeader, msg.EventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	if s.secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(s.secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// OutboxSinkOptions configures the sinks built by NewOutboxSinks
+type OutboxSinkOptions struct {
+	FilePath       string
+	WebhookURL     string
+	WebhookSecret  string
+	WebhookTimeout time.Duration
+}
+
+// NewOutboxSinks builds the named sinks
+func NewOutboxSinks(names []string, opts OutboxSinkOptions) ([]OutboxSink, error) {
+	var sinks []OutboxSink
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case SinkStdout:
+			sinks = append(sinks, NewStdoutSink())
+		case SinkFile:
+			if opts.FilePath == "" {
+				return nil, fmt.Errorf("the file sink needs a file path")
+			}
+			sink, err := NewFileSink(opts.FilePath)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case SinkWebhook:
+			if opts.WebhookURL == "" {
+				return nil, fmt.Errorf("the webhook sink needs a URL")
+			}
+			sinks = append(sinks, NewWebhookSink(opts.WebhookURL, opts.WebhookSecret, opts.WebhookTimeout))
+		default:
+			return nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+// OutboxRelay publishes outbox messages to its sinks in the background and
+// purges published messages once they are older than the retention period.
+// A message is only marked published after every sink accepted it, so
+// delivery is at least once. Messages that fail maxAttempts times are dead
+// lettered and no longer hold back later events of their reservation.
+type OutboxRelay struct {
+	outboxModel *models.OutboxModel
+	sinks       []OutboxSink
+	interval    time.Duration
+	retention   time.Duration
+	maxAttempts int
+	logger      *utils.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewOutboxRelay creates a relay that polls the outbox every interval
+func NewOutboxRelay(outboxModel *models.OutboxModel, sinks []OutboxSink, interval, retention time.Duration, maxAttempts int, logger *utils.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		outboxModel: outboxModel,
+		sinks:       sinks,
+		interval:    interval,
+		retention:   retention,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+// Start launches the relay in a background goroutine
+func (r *OutboxRelay) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(ctx)
+	}()
+
+	names := make([]string, len(r.sinks))
+	for i, sink := range r.sinks {
+		names[i] = sink.Name()
+	}
+	r.logger.Info("Outbox relay started", "interval", r.interval.String(), "sinks", strings.Join(names, ","))
+}
+
+// Stop signals the relay to stop and waits for an in-flight batch to finish
+func (r *OutboxRelay) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	r.wg.Wait()
+	r.logger.Info("Outbox relay stopped")
+}
+
+// run relays once immediately and then on every tick until ctx is cancelled
+func (r *OutboxRelay) run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayPending(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("Failed to relay outbox", "error", err)
+		}
+
+		purged, err := r.outboxModel.DeletePublished(ctx, time.Now().UTC().Add(-r.retention))
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Error("Failed to purge outbox", "error", err)
+			}
+		} else if purged > 0 {
+			r.logger.Debug("Purged published outbox messages", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending publishes every due message and returns how many were
+// published. Messages are leased in short transactions and published outside
+// of any transaction, so a slow or dead sink never holds database locks; a
+// crash mid-batch only causes messages to be published again once their
+// lease ends.
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		claimed, published, err := r.relayBatch(ctx)
+		total += published
+		if err != nil {
+			return total, err
+		}
+		// A short batch means the outbox is drained; a partly published one
+		// means a sink is failing, so wait for the next pass.
+		if claimed < outboxBatchSize || published < claimed || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+// relayBatch leases and publishes one batch and returns how many messages
+// were claimed and published. The batch stops at the first failure, since a
+// sink that just failed will most likely fail for the rest of the batch too,
+// and when half the lease is used up; unattempted messages are released.
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, int, error) {
+	claimedAt := time.Now().UTC()
+	messages, err := r.outboxModel.ClaimHeads(ctx, claimedAt, outboxLease, outboxBatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	published := 0
+	for i, msg := range messages {
+		if ctx.Err() != nil || time.Since(claimedAt) > outboxLease/2 {
+			return len(messages), published, r.release(messages[i:])
+		}
+
+		if err := r.publish(ctx, msg); err != nil {
+			now := time.Now().UTC()
+			attempt := msg.Attempts + 1
+			deadLettered, markErr := r.outboxModel.MarkFailed(context.WithoutCancel(ctx), msg.ID, err.Error(),
+				now, now.Add(outboxBackoff(attempt)), r.maxAttempts)
+			if markErr != nil {
+				return len(messages), published, markErr
+			}
+
+			if deadLettered {
+				r.logger.Error("Outbox message dead lettered",
+					"id", msg.ID,
+					"reservationId", msg.AggregateID,
+					"attempts", attempt,
+					"error", err,
+				)
+			} else {
+				r.logger.Warn("Failed to publish outbox message",
+					"id", msg.ID,
+					"reservationId", msg.AggregateID,
+					"attempt", attempt,
+					"error", err,
+				)
+			}
+			return len(messages), published, r.release(messages[i+1:])
+		}
+
+		if err := r.outboxModel.MarkPublished(context.WithoutCancel(ctx), msg.ID, time.Now().UTC()); err != nil {
+			return len(messages), published, err
+		}
+		published++
+	}
+
+	return len(messages), published, nil
+}
+
+// release ends the leases of messages the batch did not attempt
+func (r *OutboxRelay) release(messages []*models.OutboxMessage) error {
+	ids := make([]int64, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+	return r.outboxModel.Release(context.Background(), ids)
+}
+
+// publish sends a message to every sink
+func (r *OutboxRelay) publish(ctx context.Context, msg *models.OutboxMessage) error {
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, msg); err != nil {
+			return fmt.Errorf("%s sink: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+// outboxBackoff returns the delay after the given failed attempt (starting at 1)
+func outboxBackoff(attempt int) time.Duration {
+	delay := outboxBaseDelay
+	for i := 1; i < attempt && delay < outboxMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxDelay {
+		delay = outboxMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// expiryBatchSize limits how many reservations are expired per transaction
+const expiryBatchSize = 500
+
+// ExpireReservations marks every reserved reservation whose expiry has passed
+// as expired and returns how many were expired
+func (s *NameGeneratorService) ExpireReservations(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.ExpireReservations")
+	defer func() { tracing.End(span, err) }()
+
+	total := 0
+	for {
+		var expired []*models.Reservation
+		err := s.txRunner.Run(ctx, "ExpireReservations", func(tx *sql.Tx) error {
+			var err error
+			expired, err = s.reservationModel.ExpireStale(ctx, tx, time.Now().UTC(), expiryBatchSize)
+			if err != nil {
+				return err
+			}
+
+			for _, r := range expired {
+				before := *r
+				before.Status = models.StatusReserved
+				if err := s.recordEvent(ctx, tx, models.EventExpired, &before, r); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+
+		for _, r := range expired {
+			s.logger.Debug("Reservation expired", "id", r.ID, "serverName", r.ServerName)
+		}
+
+		total += len(expired)
+		if len(expired) < expiryBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// ExpiryReaper periodically expires stale reservations, reclaims names whose
+// quarantine has ended and purges expired idempotency keys in the background
+type ExpiryReaper struct {
+	nameService      *NameGeneratorService
+	idempotencyModel *models.IdempotencyModel
+	interval         time.Duration
+	logger           *utils.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewExpiryReaper creates a new expiry reaper that runs every interval
+func NewExpiryReaper(nameService *NameGeneratorService, idempotencyModel *models.IdempotencyModel, interval time.Duration, logger *utils.Logger) *ExpiryReaper {
+	return &ExpiryReaper{
+		nameService:      nameService,
+		idempotencyModel: idempotencyModel,
+		interval:         interval,
+		logger:           logger,
+	}
+}
+
+// Start launches the reaper in a background goroutine
+func (r *ExpiryReaper) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(ctx)
+	}()
+
+	r.logger.Info("Expiry reaper started", "interval", r.interval.String())
+}
+
+// Stop signals the reaper to stop and waits for an in-flight sweep to finish
+func (r *ExpiryReaper) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	r.wg.Wait()
+	r.logger.Info("Expiry reaper stopped")
+}
+
+// run sweeps once immediately and then on every tick until ctx is cancelled
+func (r *ExpiryReaper) run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep runs every expiry task once
+func (r *ExpiryReaper) sweep(ctx context.Context) {
+	count, err := r.nameService.ExpireReservations(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to expire reservations", "error", err)
+		}
+	} else if count > 0 {
+		r.logger.Info("Expired stale reservations", "count", count)
+	}
+
+	reclaimed, err := r.nameService.ReclaimQuarantinedNames(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to reclaim quarantined names", "error", err)
+		}
+	} else if reclaimed > 0 {
+		r.logger.Info("Reclaimed quarantined names", "count", reclaimed)
+	}
+
+	purged, err := r.idempotencyModel.DeleteExpired(ctx, time.Now().UTC())
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to purge idempotency keys", "error", err)
+		}
+	} else if purged > 0 {
+		r.logger.Debug("Purged expired idempotency keys", "count", purged)
+	}
+}
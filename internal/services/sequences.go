@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/errors"
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/tracing"
+)
+
+// sequenceAlphabet is used for sequences rolled past the numeric range
+const sequenceAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// pow returns base raised to exp for small non-negative integers
+func pow(base, exp int) int {
+	result := 1
+	for i := 0; i < exp; i++ {
+		result *= base
+	}
+	return result
+}
+
+// NumericCapacity returns the highest sequence number that fits in width digits
+func NumericCapacity(width int) int {
+	return pow(10, width) - 1
+}
+
+// SequenceCapacity returns the highest sequence number usable under a policy.
+// The alphabet policy continues past the numeric range with sequences that
+// start with a letter, so they can never collide with numeric ones.
+func SequenceCapacity(width int, policy string) int {
+	if policy == models.OverflowAlphabet {
+		return NumericCapacity(width) + 26*pow(36, width-1)
+	}
+	return NumericCapacity(width)
+}
+
+// FormatSequence renders a sequence number in width characters. Numbers past
+// the numeric range are rendered as a letter followed by base-36 digits.
+// Callers must check the number against SequenceCapacity first.
+func FormatSequence(sequenceNum, width int) string {
+	if sequenceNum <= NumericCapacity(width) {
+		return fmt.Sprintf("%0*d", width, sequenceNum)
+	}
+
+	offset := sequenceNum - NumericCapacity(width) - 1
+	block := pow(36, width-1)
+	lead := offset / block
+	if lead >= 26 {
+		return fmt.Sprintf("%d", sequenceNum)
+	}
+
+	var b strings.Builder
+	b.WriteByte(byte('A' + lead))
+	rest := offset % block
+	digits := make([]byte, width-1)
+	for i := len(digits) - 1; i >= 0; i-- {
+		digits[i] = sequenceAlphabet[rest%36]
+		rest /= 36
+	}
+	b.Write(digits)
+	return b.String()
+}
+
+// ParseSequence reverses FormatSequence. It reports false when value is not a
+// valid sequence of the given width.
+func ParseSequence(value string, width int) (int, bool) {
+	value = strings.ToUpper(value)
+	if len(value) != width || width == 0 {
+		return 0, false
+	}
+
+	if value[0] >= '0' && value[0] <= '9' {
+		n := 0
+		for _, c := range value {
+			if c < '0' || c > '9' {
+				return 0, false
+			}
+			n = n*10 + int(c-'0')
+		}
+		return n, true
+	}
+
+	if value[0] < 'A' || value[0] > 'Z' {
+		return 0, false
+	}
+
+	offset := int(value[0]-'A') * pow(36, width-1)
+	rest := 0
+	for _, c := range value[1:] {
+		d := strings.IndexRune(sequenceAlphabet, c)
+		if d < 0 {
+			return 0, false
+		}
+		rest = rest*36 + d
+	}
+	return NumericCapacity(width) + 1 + offset + rest, true
+}
+
+// allocateSequence picks the next sequence number for a key inside tx. In
+// gap-fill mode the lowest free number in the numeric range is used and the
+// counter only moves forward; otherwise the counter's next value is taken.
+// Numbers past the numeric range are handled by the key's overflow policy.
+func (s *NameGeneratorService) allocateSequence(ctx context.Context, tx *sql.Tx, scheme *models.NamingScheme, key models.SequenceKey, prefix string) (int, error) {
+	settings, err := s.sequenceModel.GetSettings(ctx, tx, key)
+	if err != nil {
+		return 0, errors.NewDatabaseError("Failed to get sequence settings", err)
+	}
+
+	numericMax := NumericCapacity(scheme.SequenceWidth)
+	cooldown := time.Duration(settings.ReuseCooldownSeconds) * time.Second
+
+	if settings.AllocationMode == models.AllocationGapFill {
+		free, err := s.reservationModel.FindLowestFreeSequence(ctx, tx, key, numericMax, cooldown)
+		if err != nil {
+			return 0, errors.NewDatabaseError("Failed to find a free sequence number", err)
+		}
+		if free > 0 {
+			if err := s.sequenceModel.AdvanceSequenceNumber(ctx, tx, key, free); err != nil {
+				return 0, errors.NewDatabaseError("Failed to update sequence", err)
+			}
+			return free, nil
+		}
+	}
+
+	// Atomically allocate the next number from the per-key counter
+	sequenceNum, err := s.sequenceModel.GetNextSequenceNumber(ctx, tx, key)
+	if err != nil {
+		return 0, errors.NewDatabaseError("Failed to allocate sequence number", err)
+	}
+
+	if sequenceNum <= numericMax {
+		return sequenceNum, nil
+	}
+
+	switch settings.OverflowPolicy {
+	case models.OverflowAlphabet:
+		if sequenceNum <= SequenceCapacity(scheme.SequenceWidth, settings.OverflowPolicy) {
+			return sequenceNum, nil
+		}
+	case models.OverflowReuse:
+		free, err := s.reservationModel.FindLowestFreeSequence(ctx, tx, key, numericMax, cooldown)
+		if err != nil {
+			return 0, errors.NewDatabaseError("Failed to find a free sequence number", err)
+		}
+		if free > 0 {
+			// Keep the counter pinned at the top of the range
+			if err := s.sequenceModel.SetSequenceNumber(ctx, tx, key, numericMax); err != nil {
+				return 0, errors.NewDatabaseError("Failed to update sequence", err)
+			}
+			return free, nil
+		}
+	}
+
+	s.logger.WithRequestID(ctx).Warn("Sequence prefix exhausted", "prefix", prefix, "policy", settings.OverflowPolicy)
+	return 0, errors.NewConflictError(fmt.Sprintf("Prefix %s is exhausted", prefix)).
+		WithCode("prefix_exhausted")
+}
+
+// peekSequence returns the number allocateSequence would pick for a key
+// without writing anything: the lowest free number in gap-fill mode or once
+// the reuse policy applies, and otherwise the counter's current value plus
+// one. It takes no locks, so a concurrent reservation may take the number
+// first.
+func (s *NameGeneratorService) peekSequence(ctx context.Context, tx *sql.Tx, scheme *models.NamingScheme, key models.SequenceKey, prefix string) (int, error) {
+	settings, err := s.sequenceModel.GetSettings(ctx, tx, key)
+	if err != nil {
+		return 0, errors.NewDatabaseError("Failed to get sequence settings", err)
+	}
+
+	numericMax := NumericCapacity(scheme.SequenceWidth)
+	cooldown := time.Duration(settings.ReuseCooldownSeconds) * time.Second
+
+	if settings.AllocationMode == models.AllocationGapFill {
+		free, err := s.reservationModel.FindLowestFreeSequence(ctx, tx, key, numericMax, cooldown)
+		if err != nil {
+			return 0, errors.NewDatabaseError("Failed to find a free sequence number", err)
+		}
+		if free > 0 {
+			return free, nil
+		}
+	}
+
+	current, err := s.sequenceModel.GetCurrentSequenceNumber(ctx, key)
+	if err != nil {
+		return 0, errors.NewDatabaseError("Failed to get sequence number", err)
+	}
+	sequenceNum := current + 1
+
+	if sequenceNum <= numericMax {
+		return sequenceNum, nil
+	}
+
+	switch settings.OverflowPolicy {
+	case models.OverflowAlphabet:
+		if sequenceNum <= SequenceCapacity(scheme.SequenceWidth, settings.OverflowPolicy) {
+			return sequenceNum, nil
+		}
+	case models.OverflowReuse:
+		free, err := s.reservationModel.FindLowestFreeSequence(ctx, tx, key, numericMax, cooldown)
+		if err != nil {
+			return 0, errors.NewDatabaseError("Failed to find a free sequence number", err)
+		}
+		if free > 0 {
+			return free, nil
+		}
+	}
+
+	return 0, errors.NewConflictError(fmt.Sprintf("Prefix %s is exhausted", prefix)).
+		WithCode("prefix_exhausted")
+}
+
+// UpdateSequenceSettings changes the allocation settings for a sequence key.
+// Nil values are left unchanged.
+func (s *NameGeneratorService) UpdateSequenceSettings(ctx context.Context, key models.SequenceKey, overflowPolicy, allocationMode *string, reuseCooldownSeconds *int) (err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.UpdateSequenceSettings")
+	defer func() { tracing.End(span, err) }()
+
+	key = normalizeSequenceKey(key)
+
+	if err := s.sequenceModel.UpdateSettings(ctx, key, overflowPolicy, allocationMode, reuseCooldownSeconds); err != nil {
+		return errors.NewDatabaseError("Failed to update sequence settings", err)
+	}
+
+	s.logger.Info("Sequence settings updated", "key", key)
+	return nil
+}
+
+// normalizeSequenceKey upper-cases a key so it matches stored segment values
+func normalizeSequenceKey(key models.SequenceKey) models.SequenceKey {
+	return models.SequenceKey{
+		UnitCode:    strings.ToUpper(key.UnitCode),
+		Type:        strings.ToUpper(key.Type),
+		Provider:    strings.ToUpper(key.Provider),
+		Region:      strings.ToUpper(key.Region),
+		Environment: strings.ToUpper(key.Environment),
+		Function:    strings.ToUpper(key.Function),
+	}
+}
+
+// GetSequences returns every sequence counter
+func (s *NameGeneratorService) GetSequences(ctx context.Context) (_ []*models.Sequence, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.GetSequences")
+	defer func() { tracing.End(span, err) }()
+
+	sequences, err := s.sequenceModel.GetAll(ctx)
+	if err != nil {
+		return nil, errors.NewDatabaseError("Failed to get sequences", err)
+	}
+	return sequences, nil
+}
+
+// SeedSequence sets the counter for a key. The value cannot be lower than the
+// highest number already reserved for the key, otherwise the next allocation
+// would collide with an existing name.
+func (s *NameGeneratorService) SeedSequence(ctx context.Context, key models.SequenceKey, value int) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.SeedSequence")
+	defer func() { tracing.End(span, err) }()
+
+	key = normalizeSequenceKey(key)
+
+	err = s.txRunner.Run(ctx, "SeedSequence", func(tx *sql.Tx) error {
+		highest, err := s.reservationModel.FindHighestSequenceForKey(ctx, tx, key)
+		if err != nil {
+			return errors.NewDatabaseError("Failed to find highest reserved sequence", err)
+		}
+
+		if value < highest {
+			return errors.NewConflictError(
+				fmt.Sprintf("Sequence value %d is below the highest reserved sequence %d", value, highest))
+		}
+
+		if err := s.sequenceModel.SetSequenceNumber(ctx, tx, key, value); err != nil {
+			return errors.NewDatabaseError("Failed to set sequence", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, transactionError(err, "Failed to seed sequence")
+	}
+
+	s.logger.Info("Sequence seeded", "key", key, "value", value)
+	return value, nil
+}
+
+// ResetSequence moves the counter for a key back to the highest number still
+// held by a reservation, so numbers freed at the top of the range are reused
+func (s *NameGeneratorService) ResetSequence(ctx context.Context, key models.SequenceKey) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "NameGeneratorService.ResetSequence")
+	defer func() { tracing.End(span, err) }()
+
+	key = normalizeSequenceKey(key)
+
+	var highest int
+	err = s.txRunner.Run(ctx, "ResetSequence", func(tx *sql.Tx) error {
+		var err error
+		highest, err = s.reservationModel.FindHighestSequenceForKey(ctx, tx, key)
+		if err != nil {
+			return errors.NewDatabaseError("Failed to find highest reserved sequence", err)
+		}
+
+		if err := s.sequenceModel.SetSequenceNumber(ctx, tx, key, highest); err != nil {
+			return errors.NewDatabaseError("Failed to reset sequence", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, transactionError(err, "Failed to reset sequence")
+	}
+
+	s.logger.Info("Sequence reset", "key", key, "value", highest)
+	return highest, nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+)
+
+func TestSequenceCapacity(t *testing.T) {
+	tests := []struct {
+		width  int
+		policy string
+		want   int
+	}{
+		{1, models.OverflowReject, 9},
+		{3, models.OverflowReject, 999},
+		{3, models.OverflowReuse, 999},
+		{1, models.OverflowAlphabet, 9 + 26},
+		{2, models.OverflowAlphabet, 99 + 26*36},
+		{3, models.OverflowAlphabet, 999 + 26*36*36},
+	}
+
+	for _, tt := range tests {
+		if got := SequenceCapacity(tt.width, tt.policy); got != tt.want {
+			t.Errorf("SequenceCapacity(%d, %q) = %d, want %d", tt.width, tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSequence(t *testing.T) {
+	tests := []struct {
+		sequenceNum int
+		width       int
+		want        string
+	}{
+		{1, 3, "001"},
+		{42, 3, "042"},
+		{999, 3, "999"},
+		{1000, 3, "A00"},
+		{1009, 3, "A09"},
+		{1010, 3, "A0A"},
+		{1035, 3, "A0Z"},
+		{1036, 3, "A10"},
+		{999 + 36*36, 3, "AZZ"},
+		{1000 + 36*36, 3, "B00"},
+		{SequenceCapacity(3, models.OverflowAlphabet), 3, "ZZZ"},
+		{9, 1, "9"},
+		{10, 1, "A"},
+		{35, 1, "Z"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSequence(tt.sequenceNum, tt.width); got != tt.want {
+			t.Errorf("FormatSequence(%d, %d) = %q, want %q", tt.sequenceNum, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestParseSequence(t *testing.T) {
+	tests := []struct {
+		value  string
+		width  int
+		want   int
+		wantOK bool
+	}{
+		{"001", 3, 1, true},
+		{"999", 3, 999, true},
+		{"A00", 3, 1000, true},
+		{"a0z", 3, 1035, true},
+		{"ZZZ", 3, SequenceCapacity(3, models.OverflowAlphabet), true},
+		{"01", 3, 0, false},
+		{"0001", 3, 0, false},
+		{"", 0, 0, false},
+		{"9A9", 3, 0, false},
+		{"-01", 3, 0, false},
+		{"A-0", 3, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseSequence(tt.value, tt.width)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("ParseSequence(%q, %d) = %d, %v; want %d, %v", tt.value, tt.width, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSequenceRoundTrip(t *testing.T) {
+	for _, width := range []int{1, 2, 3} {
+		numericMax := NumericCapacity(width)
+		capacity := SequenceCapacity(width, models.OverflowAlphabet)
+
+		// Around the numeric/alphabet boundary and the top of the range
+		for _, n := range []int{1, numericMax - 1, numericMax, numericMax + 1, numericMax + 2, capacity - 1, capacity} {
+			formatted := FormatSequence(n, width)
+			if len(formatted) != width {
+				t.Errorf("FormatSequence(%d, %d) = %q, want %d characters", n, width, formatted, width)
+			}
+			got, ok := ParseSequence(formatted, width)
+			if !ok || got != n {
+				t.Errorf("ParseSequence(FormatSequence(%d, %d) = %q) = %d, %v", n, width, formatted, got, ok)
+			}
+		}
+	}
+}
+
+func TestReserveServerNameOverflow(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	// reserve fills a prefix up to the numeric limit under a policy and
+	// returns the name handed out next
+	reserve := func(t *testing.T, function, policy string, held int) (string, error) {
+		t.Helper()
+		params := webPayload
+		params.Function = function
+		key := models.SequenceKeyFor(params)
+
+		for i := 0; i < held; i++ {
+			if _, err := s.ReserveServerName(ctx, params); err != nil {
+				t.Fatalf("ReserveServerName() error = %v", err)
+			}
+		}
+		if err := s.UpdateSequenceSettings(ctx, key, &policy, nil, nil); err != nil {
+			t.Fatalf("UpdateSequenceSettings() error = %v", err)
+		}
+		if _, err := s.SeedSequence(ctx, key, NumericCapacity(3)); err != nil {
+			t.Fatalf("SeedSequence() error = %v", err)
+		}
+
+		resp, err := s.ReserveServerName(ctx, params)
+		if err != nil {
+			return "", err
+		}
+		return resp.ServerName, nil
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		_, err := reserve(t, "RJ", models.OverflowReject, 0)
+		if code := errorCode(err); code != "prefix_exhausted" {
+			t.Fatalf("ReserveServerName() error = %v, want prefix_exhausted", err)
+		}
+	})
+
+	t.Run("alphabet", func(t *testing.T) {
+		name, err := reserve(t, "AL", models.OverflowAlphabet, 0)
+		if err != nil || name != "ABCVAWEU1PALA00" {
+			t.Fatalf("ReserveServerName() = %q, %v; want ABCVAWEU1PALA00", name, err)
+		}
+	})
+
+	t.Run("reuse skips held numbers", func(t *testing.T) {
+		name, err := reserve(t, "RU", models.OverflowReuse, 2)
+		if err != nil || name != "ABCVAWEU1PRU003" {
+			t.Fatalf("ReserveServerName() = %q, %v; want ABCVAWEU1PRU003", name, err)
+		}
+	})
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// Webhook request headers
+const (
+	WebhookIDHeader        = "X-Webhook-ID"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// webhookBatchSize limits how many deliveries are claimed at once
+const webhookBatchSize = 50
+
+// SignWebhook returns the signature header value for a payload: the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the subscription secret.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the signature of a received webhook and rejects
+// timestamps further than tolerance from now, to stop replays
+func VerifyWebhook(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp")
+	}
+
+	age := now.Sub(time.Unix(ts, 0))
+	if age < -tolerance || age > tolerance {
+		return fmt.Errorf("timestamp outside tolerance")
+	}
+
+	if !hmac.Equal([]byte(SignWebhook(secret, ts, body)), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// WebhookRetryPolicy controls webhook delivery attempts
+type WebhookRetryPolicy struct {
+	MaxAttempts int           // Attempts before a delivery becomes a dead letter
+	BaseDelay   time.Duration // Delay after the first failure, doubled on each failure
+	MaxDelay    time.Duration // Upper bound for a single delay
+	Timeout     time.Duration // Timeout for a single request
+}
+
+// backoff returns the delay after the given failed attempt (starting at 1)
+func (p WebhookRetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// webhookQueue is the delivery queue the dispatcher works on, implemented
+// by models.WebhookModel
+type webhookQueue interface {
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id int64, statusCode int, now time.Time) error
+	MarkFailed(ctx context.Context, id int64, statusCode int, lastError string, nextAttemptAt time.Time, dead bool) error
+	DeleteDelivered(ctx context.Context, before time.Time) (int64, error)
+}
+
+// WebhookDispatcher delivers queued webhook deliveries in the background,
+// retrying failures with exponential backoff, and purges delivered
+// deliveries once they are older than the retention period
+type WebhookDispatcher struct {
+	webhookModel webhookQueue
+	client       *http.Client
+	policy       WebhookRetryPolicy
+	interval     time.Duration
+	retention    time.Duration
+	logger       *utils.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWebhookDispatcher creates a dispatcher that polls the queue every
+// interval and keeps delivered deliveries for retention. A nil client uses a
+// client with the policy's timeout.
+func NewWebhookDispatcher(webhookModel *models.WebhookModel, client *http.Client, policy WebhookRetryPolicy, interval, retention time.Duration, logger *utils.Logger) *WebhookDispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: policy.Timeout}
+	}
+	return &WebhookDispatcher{
+		webhookModel: webhookModel,
+		client:       client,
+		policy:       policy,
+		interval:     interval,
+		retention:    retention,
+		logger:       logger,
+	}
+}
+
+// Start launches the dispatcher in a background goroutine
+func (d *WebhookDispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.run(ctx)
+	}()
+
+	d.logger.Info("Webhook dispatcher started", "interval", d.interval.String())
+}
+
+// Stop signals the dispatcher to stop and waits for in-flight deliveries
+func (d *WebhookDispatcher) Stop() {
+	if d.cancel == nil {
+		return
+	}
+	d.cancel()
+	d.wg.Wait()
+	d.logger.Info("Webhook dispatcher stopped")
+}
+
+// run delivers and purges once immediately and then on every tick until ctx is cancelled
+func (d *WebhookDispatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			d.logger.Error("Failed to deliver webhooks", "error", err)
+		}
+
+		purged, err := d.webhookModel.DeleteDelivered(ctx, time.Now().UTC().Add(-d.retention))
+		if err != nil {
+			if ctx.Err() == nil {
+				d.logger.Error("Failed to purge webhook deliveries", "error", err)
+			}
+		} else if purged > 0 {
+			d.logger.Debug("Purged delivered webhooks", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts every delivery that is due and returns how many were
+// attempted
+func (d *WebhookDispatcher) DeliverDue(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		// Lease claimed deliveries for longer than a request can take
+		deliveries, err := d.webhookModel.ClaimDue(ctx, time.Now().UTC(), 2*d.policy.Timeout, webhookBatchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, delivery := range deliveries {
+			d.attempt(ctx, delivery)
+		}
+
+		total += len(deliveries)
+		if len(deliveries) < webhookBatchSize || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+// attempt sends one delivery and records the outcome
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	now := time.Now().UTC()
+	statusCode, err := d.send(ctx, delivery, now)
+	if err == nil {
+		if err := d.webhookModel.MarkDelivered(ctx, delivery.ID, statusCode, now); err != nil {
+			d.logger.Error("Failed to record webhook delivery", "error", err, "deliveryId", delivery.ID)
+		}
+		d.logger.Debug("Webhook delivered", "deliveryId", delivery.ID, "event", delivery.EventType)
+		return
+	}
+
+	dead := delivery.Attempts >= d.policy.MaxAttempts
+	next := now.Add(d.policy.backoff(delivery.Attempts))
+	if err := d.webhookModel.MarkFailed(ctx, delivery.ID, statusCode, err.Error(), next, dead); err != nil {
+		d.logger.Error("Failed to record webhook failure", "error", err, "deliveryId", delivery.ID)
+	}
+
+	if dead {
+		d.logger.Warn("Webhook delivery moved to dead letters",
+			"deliveryId", delivery.ID,
+			"subscriptionId", delivery.SubscriptionID,
+			"attempts", delivery.Attempts,
+			"error", err,
+		)
+	} else {
+		d.logger.Debug("Webhook delivery failed", "deliveryId", delivery.ID, "attempt", delivery.Attempts, "error", err)
+	}
+}
+
+// send posts a signed delivery. Any 2xx response counts as delivered.
+func (d *WebhookDispatcher) send(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.policy.Timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "server-name-generator-webhooks")
+	req.Header.Set(WebhookIDHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(delivery.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	message := fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	if text := strings.TrimSpace(string(snippet)); text != "" {
+		message += ": " + text
+	}
+	return resp.StatusCode, fmt.Errorf("%s", message)
+}
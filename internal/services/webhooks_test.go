@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/models"
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+	"github.com/google/uuid"
+)
+
+// fakeWebhookQueue is an in-memory webhookQueue that hands out its
+// deliveries once and records their outcomes
+type fakeWebhookQueue struct {
+	mu        sync.Mutex
+	pending   []*models.WebhookDelivery
+	delivered map[int64]int
+	failed    map[int64]fakeFailure
+}
+
+type fakeFailure struct {
+	statusCode    int
+	nextAttemptAt time.Time
+	dead          bool
+}
+
+func newFakeWebhookQueue(deliveries ...*models.WebhookDelivery) *fakeWebhookQueue {
+	return &fakeWebhookQueue{
+		pending:   deliveries,
+		delivered: make(map[int64]int),
+		failed:    make(map[int64]fakeFailure),
+	}
+}
+
+func (q *fakeWebhookQueue) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	claimed := q.pending
+	q.pending = nil
+	for _, d := range claimed {
+		d.Attempts++
+	}
+	return claimed, nil
+}
+
+func (q *fakeWebhookQueue) MarkDelivered(ctx context.Context, id int64, statusCode int, now time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.delivered[id] = statusCode
+	return nil
+}
+
+func (q *fakeWebhookQueue) MarkFailed(ctx context.Context, id int64, statusCode int, lastError string, nextAttemptAt time.Time, dead bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.failed[id] = fakeFailure{statusCode: statusCode, nextAttemptAt: nextAttemptAt, dead: dead}
+	return nil
+}
+
+func (q *fakeWebhookQueue) DeleteDelivered(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestWebhookDispatcherDeliverDue(t *testing.T) {
+	const secret = "whsec_test"
+	policy := WebhookRetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Minute,
+		Timeout:     5 * time.Second,
+	}
+
+	tests := []struct {
+		name          string
+		status        int
+		priorAttempts int
+		wantDelivered bool
+		wantDelay     time.Duration
+		wantDead      bool
+	}{
+		{name: "delivered", status: http.StatusNoContent, wantDelivered: true},
+		{name: "first failure", status: http.StatusInternalServerError, wantDelay: time.Second},
+		{name: "backoff doubles", status: http.StatusBadGateway, priorAttempts: 2, wantDelay: 4 * time.Second},
+		{name: "dead lettered", status: http.StatusInternalServerError, priorAttempts: 4, wantDelay: 16 * time.Second, wantDead: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var headers http.Header
+			var verifyErr error
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				mu.Lock()
+				headers = r.Header.Clone()
+				verifyErr = VerifyWebhook(secret, r.Header.Get(WebhookTimestampHeader),
+					r.Header.Get(WebhookSignatureHeader), body, time.Minute, time.Now())
+				mu.Unlock()
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			queue := newFakeWebhookQueue(&models.WebhookDelivery{
+				ID:        42,
+				EventType: models.EventCommitted,
+				Payload:   json.RawMessage(`{"serverName":"ABCVAWEUPWB007"}`),
+				Attempts:  tt.priorAttempts,
+				URL:       server.URL,
+				Secret:    secret,
+			})
+			dispatcher := &WebhookDispatcher{
+				webhookModel: queue,
+				client:       server.Client(),
+				policy:       policy,
+				logger:       utils.NewLogger("error"),
+			}
+
+			start := time.Now().UTC()
+			n, err := dispatcher.DeliverDue(context.Background())
+			if err != nil {
+				t.Fatalf("DeliverDue() error = %v", err)
+			}
+			if n != 1 {
+				t.Fatalf("DeliverDue() attempted %d deliveries, want 1", n)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if headers == nil {
+				t.Fatal("receiver got no request")
+			}
+			if verifyErr != nil {
+				t.Errorf("receiver could not verify signature: %v", verifyErr)
+			}
+			if got := headers.Get(WebhookIDHeader); got != "42" {
+				t.Errorf("%s = %q, want %q", WebhookIDHeader, got, "42")
+			}
+			if got := headers.Get(WebhookEventHeader); got != models.EventCommitted {
+				t.Errorf("%s = %q, want %q", WebhookEventHeader, got, models.EventCommitted)
+			}
+
+			if tt.wantDelivered {
+				if code, ok := queue.delivered[42]; !ok || code != tt.status {
+					t.Errorf("delivered status = %d, %v; want %d", code, ok, tt.status)
+				}
+				if _, ok := queue.failed[42]; ok {
+					t.Error("delivery was also marked failed")
+				}
+				return
+			}
+
+			failure, ok := queue.failed[42]
+			if !ok {
+				t.Fatal("delivery was not marked failed")
+			}
+			if _, ok := queue.delivered[42]; ok {
+				t.Error("failed delivery was marked delivered")
+			}
+			if failure.statusCode != tt.status {
+				t.Errorf("failure status = %d, want %d", failure.statusCode, tt.status)
+			}
+			if failure.dead != tt.wantDead {
+				t.Errorf("dead = %v, want %v", failure.dead, tt.wantDead)
+			}
+			delay := failure.nextAttemptAt.Sub(start)
+			if delay < tt.wantDelay || delay > tt.wantDelay+time.Second {
+				t.Errorf("retry delay = %v, want %v", delay, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"event":"committed"}`)
+
+	// HMAC-SHA256 of `1700000000.{"event":"committed"}` keyed with "secret"
+	const want = "sha256=0f44bfe71e3528c1699a6b04855839b5ac64627a637244b83d058c71cc89eac8"
+	base := SignWebhook("secret", 1700000000, body)
+	if base != want {
+		t.Fatalf("SignWebhook() = %q, want %q", base, want)
+	}
+
+	if SignWebhook("other", 1700000000, body) == base {
+		t.Error("SignWebhook() ignores the secret")
+	}
+	if SignWebhook("secret", 1700000001, body) == base {
+		t.Error("SignWebhook() ignores the timestamp")
+	}
+	if SignWebhook("secret", 1700000000, []byte(`{"event":"released"}`)) == base {
+		t.Error("SignWebhook() ignores the body")
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"event":"committed"}`)
+	now := time.Unix(1700000000, 0)
+	signed := now.Add(-30 * time.Second).Unix()
+	signature := SignWebhook(secret, signed, body)
+	timestamp := strconv.FormatInt(signed, 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		tolerance time.Duration
+		wantErr   bool
+	}{
+		{name: "valid", secret: secret, timestamp: timestamp, signature: signature, body: body, tolerance: time.Minute},
+		{name: "at tolerance", secret: secret, timestamp: timestamp, signature: signature, body: body, tolerance: 30 * time.Second},
+		{name: "too old", secret: secret, timestamp: timestamp, signature: signature, body: body, tolerance: 29 * time.Second, wantErr: true},
+		{
+			name: "too far ahead", secret: secret, body: body, tolerance: time.Minute, wantErr: true,
+			timestamp: strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10),
+			signature: SignWebhook(secret, now.Add(2*time.Minute).Unix(), body),
+		},
+		{name: "wrong secret", secret: "other", timestamp: timestamp, signature: signature, body: body, tolerance: time.Minute, wantErr: true},
+		{name: "tampered body", secret: secret, timestamp: timestamp, signature: signature, body: []byte(`{"event":"released"}`), tolerance: time.Minute, wantErr: true},
+		{name: "timestamp not signed", secret: secret, timestamp: strconv.FormatInt(signed+1, 10), signature: signature, body: body, tolerance: time.Minute, wantErr: true},
+		{name: "malformed timestamp", secret: secret, timestamp: "yesterday", signature: signature, body: body, tolerance: time.Minute, wantErr: true},
+		{name: "missing signature", secret: secret, timestamp: timestamp, body: body, tolerance: time.Minute, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := VerifyWebhook(tt.secret, tt.timestamp, tt.signature, tt.body, tt.tolerance, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: VerifyWebhook() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWebhookRetryPolicyBackoff(t *testing.T) {
+	policy := WebhookRetryPolicy{BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{5, 5 * time.Minute},
+		{50, 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := policy.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookDispatcherDeliversEvents(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var received []string
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+
+		err := VerifyWebhook("whsec_dispatcher_test", r.Header.Get(WebhookTimestampHeader),
+			r.Header.Get(WebhookSignatureHeader), body, time.Minute, time.Now())
+		if err != nil {
+			t.Errorf("receiver could not verify signature: %v", err)
+		}
+		var event models.ReservationEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("receiver got invalid payload: %v", err)
+		}
+		received = append(received, event.Type)
+
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	now := time.Now().UTC()
+	sub := &models.WebhookSubscription{
+		ID:         uuid.New().String(),
+		URL:        server.URL,
+		Secret:     "whsec_dispatcher_test",
+		EventTypes: []string{models.EventCommitted},
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.webhookModel.Create(ctx, sub); err != nil {
+		t.Fatalf("Create() subscription error = %v", err)
+	}
+
+	// Only the commit is queued; the subscription does not want creations
+	resp, err := s.ReserveServerName(ctx, webPayload)
+	if err != nil {
+		t.Fatalf("ReserveServerName() error = %v", err)
+	}
+	if err := s.CommitReservation(ctx, resp.ReservationID, nil); err != nil {
+		t.Fatalf("CommitReservation() error = %v", err)
+	}
+
+	dispatcher := NewWebhookDispatcher(s.webhookModel, server.Client(), WebhookRetryPolicy{
+		MaxAttempts: 2,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Millisecond,
+		Timeout:     5 * time.Second,
+	}, time.Hour, time.Hour, utils.NewLogger("error"))
+
+	deliver := func(want int) {
+		t.Helper()
+		time.Sleep(5 * time.Millisecond) // let the retry delay pass
+		if n, err := dispatcher.DeliverDue(ctx); err != nil || n != want {
+			t.Fatalf("DeliverDue() = %d, %v; want %d", n, err, want)
+		}
+	}
+
+	// Two failed attempts use up MaxAttempts
+	deliver(1)
+	deliver(1)
+	deliver(0)
+
+	dead, err := s.webhookModel.GetDeadLetters(ctx, sub.ID, 10)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("GetDeadLetters() = %v, %v; want one dead letter", dead, err)
+	}
+	if dead[0].LastStatusCode != http.StatusServiceUnavailable {
+		t.Errorf("dead letter status = %d, want %d", dead[0].LastStatusCode, http.StatusServiceUnavailable)
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	if ok, err := s.webhookModel.Redeliver(ctx, dead[0].ID, time.Now().UTC()); err != nil || !ok {
+		t.Fatalf("Redeliver() = %v, %v", ok, err)
+	}
+	deliver(1)
+	deliver(0)
+
+	if dead, err := s.webhookModel.GetDeadLetters(ctx, sub.ID, 10); err != nil || len(dead) != 0 {
+		t.Errorf("GetDeadLetters() after redelivery = %v, %v; want none", dead, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{models.EventCommitted, models.EventCommitted, models.EventCommitted}
+	if len(received) != len(want) {
+		t.Fatalf("receiver got %v, want %v", received, want)
+	}
+	for i := range want {
+		if received[i] != want[i] {
+			t.Errorf("request %d event = %q, want %q", i+1, received[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/utils"
+)
+
+// TraceIDHeader is the response header carrying the trace ID of a request
+const TraceIDHeader = "X-Trace-ID"
+
+// Middleware starts a server span for each request, continuing the trace of
+// an incoming traceparent header. The span is named after the matched chi
+// route and carries the request ID, and the trace ID is returned in the
+// X-Trace-ID header. It must run after RequestIDMiddleware.
+func Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			ctx, span := otel.Tracer(instrumentationName).Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			if requestID, ok := ctx.Value(utils.RequestIDKey).(string); ok {
+				span.SetAttributes(RequestIDKey.String(requestID))
+			}
+			if traceID := TraceID(ctx); traceID != "" {
+				w.Header().Set(TraceIDHeader, traceID)
+			}
+
+			rw := utils.NewResponseWriter(w)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			// The route is only known once chi has matched the request.
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if route := rctx.RoutePattern(); route != "" {
+					span.SetName(r.Method + " " + route)
+					span.SetAttributes(semconv.HTTPRoute(route))
+				}
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(rw.StatusCode))
+			if rw.StatusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rw.StatusCode))
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"runtime"
+	"strings"
+
+	"github.com/XSAM/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// modelsPackage is the package whose methods name SQL spans
+const modelsPackage = instrumentationName + "/internal/models."
+
+// SQLOptions configures otelsql to trace each statement run on behalf of a
+// traced operation. Spans are named after the model method that ran the
+// statement, such as ReservationModel.IsServerNameUnique.
+func SQLOptions() []otelsql.Option {
+	return []otelsql.Option{
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanNameFormatter(sqlSpanName),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			OmitConnectorConnect: true,
+			// Background polling without a parent span is not traced.
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	}
+}
+
+// sqlSpanName names a statement span after the calling model method, falling
+// back to the otelsql method name for statements run outside the models
+func sqlSpanName(_ context.Context, method otelsql.Method, _ string) string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, modelsPackage); ok {
+			// (*ReservationModel).IsServerNameUnique -> ReservationModel.IsServerNameUnique
+			name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+			// Drop closure suffixes such as .func1
+			if i := strings.Index(name, ".func"); i > 0 {
+				name = name[:i]
+			}
+			return name
+		}
+		if !more {
+			return string(method)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Package tracing sets up OpenTelemetry tracing for the HTTP, service and
+// SQL layers
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bilbothegreedy/server-name-generator/internal/config"
+	apperrors "github.com/bilbothegreedy/server-name-generator/internal/errors"
+)
+
+// instrumentationName identifies the spans created by this application
+const instrumentationName = "github.com/bilbothegreedy/server-name-generator"
+
+// Trace exporters
+const (
+	ExporterNone   = "none"   // Tracing disabled
+	ExporterOTLP   = "otlp"   // OTLP over HTTP to TRACING_OTLP_ENDPOINT
+	ExporterStdout = "stdout" // One JSON span per line on standard output
+	ExporterFile   = "file"   // One JSON span per line appended to TRACING_FILE_PATH
+)
+
+// RequestIDKey is the span attribute holding the request ID of a request
+const RequestIDKey = attribute.Key("request.id")
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator. The returned function flushes pending spans and must be called
+// on shutdown. With the none exporter nothing is recorded, but incoming
+// traceparent headers are still propagated.
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var closer func() error
+	switch strings.ToLower(cfg.Exporter) {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		exp, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		exporter = exp
+
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout trace exporter: %w", err)
+		}
+		exporter = exp
+
+	case ExporterFile:
+		if cfg.FilePath == "" {
+			return nil, errors.New("the file trace exporter needs TRACING_FILE_PATH")
+		}
+		file, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to create file trace exporter: %w", err)
+		}
+		exporter = exp
+		closer = file.Close
+
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	shutdown := func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}
+	return shutdown, nil
+}
+
+// Start starts an internal span named name as a child of the span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, recording err when it is set. Only server-side failures
+// mark the span as failed; validation, not found and conflict errors are
+// expected outcomes and are recorded as events.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+
+		var appErr *apperrors.AppError
+		if errors.As(err, &appErr) {
+			span.SetAttributes(attribute.String("error.type", string(appErr.Type)))
+		}
+		if appErr == nil || appErr.Type == apperrors.ErrorTypeInternal || appErr.Type == apperrors.ErrorTypeDatabase {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+// TraceID returns the ID of the trace in ctx, or an empty string
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
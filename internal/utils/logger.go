@@ -7,6 +7,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Logger is a wrapper around slog.Logger with predefined methods
@@ -57,7 +59,7 @@ func NewLogger(level string) *Logger {
 	return &Logger{logger}
 }
 
-// WithRequestID adds request ID to logger
+// WithRequestID adds request ID and trace ID to logger
 func (l *Logger) WithRequestID(ctx context.Context) *Logger {
 	logger := l.Logger
 
@@ -66,6 +68,11 @@ func (l *Logger) WithRequestID(ctx context.Context) *Logger {
 		logger = logger.With("request_id", requestID)
 	}
 
+	// Add trace ID so logs can be matched with traces
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		logger = logger.With("trace_id", sc.TraceID().String())
+	}
+
 	return &Logger{logger}
 }
 
@@ -0,0 +1,23 @@
+package utils
+
+import "context"
+
+// PrincipalKey is the context key for the authenticated principal
+const PrincipalKey ContextKey = "principal"
+
+// Principal identifies who made a request
+type Principal struct {
+	UserID   string // Authenticated user, or the owner of the API key
+	APIKeyID string // API key used for the request, empty for JWT requests
+}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, PrincipalKey, p)
+}
+
+// PrincipalFromContext returns the principal stored in ctx, if any
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(PrincipalKey).(Principal)
+	return p, ok
+}
@@ -50,6 +50,18 @@ func (rw *CustomResponseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush sends buffered data to the client when the underlying writer supports it
+func (rw *CustomResponseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (rw *CustomResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // ErrorResponse represents an error response to be sent to the client
 type ErrorResponse struct {
 	Status    int         `json:"status"`
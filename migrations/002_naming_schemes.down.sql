@@ -0,0 +1,2 @@
+ALTER TABLE reservations DROP COLUMN IF EXISTS scheme_id;
+DROP TABLE IF EXISTS naming_schemes;
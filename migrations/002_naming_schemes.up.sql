@@ -0,0 +1,61 @@
+-- Reservations were historically created at startup; make sure the table exists before altering it
+CREATE TABLE IF NOT EXISTS reservations (
+    id UUID PRIMARY KEY,
+    server_name VARCHAR(100) NOT NULL UNIQUE,
+    unit_code VARCHAR(10) NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    region VARCHAR(10) NOT NULL,
+    environment VARCHAR(10) NOT NULL,
+    function VARCHAR(20) NOT NULL,
+    sequence_num INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservations_status ON reservations (status);
+
+-- Create naming schemes table
+CREATE TABLE IF NOT EXISTS naming_schemes (
+    id UUID PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    version INTEGER NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    separator VARCHAR(5) NOT NULL DEFAULT '',
+    letter_case VARCHAR(10) NOT NULL DEFAULT 'upper',
+    segments JSONB NOT NULL,
+    sequence_width INTEGER NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (name, version)
+);
+
+-- Only one scheme can be the default
+CREATE UNIQUE INDEX IF NOT EXISTS idx_naming_schemes_default
+ON naming_schemes (is_default) WHERE is_default;
+
+-- Seed the compact format used by the Windows fleet and the dashed lowercase Linux format
+INSERT INTO naming_schemes (id, name, version, description, separator, letter_case, segments, sequence_width, is_default)
+VALUES
+    ('6f1c2a8e-3b7d-4c55-9a10-0c1f1d3e5a01', 'compact', 1,
+     'Fixed-width UnitCode+Type+Provider+Region+Environment+Function+Sequence', '', 'upper',
+     '[{"field":"unitCode","length":3,"charset":"alphanumeric","default":"SRV"},
+       {"field":"type","length":1,"charset":"alphanumeric","default":"V"},
+       {"field":"provider","length":1,"charset":"alphanumeric","default":"X"},
+       {"field":"region","length":4,"charset":"alphanumeric","default":"GLBL"},
+       {"field":"environment","length":1,"charset":"alphanumeric","default":"P"},
+       {"field":"function","length":2,"charset":"alphanumeric","default":"SV"}]',
+     3, TRUE),
+    ('6f1c2a8e-3b7d-4c55-9a10-0c1f1d3e5a02', 'linux', 1,
+     'Dash separated lowercase unit-region-environment-function-sequence', '-', 'lower',
+     '[{"field":"unitCode","length":3,"charset":"alphanumeric","default":"SRV"},
+       {"field":"region","length":4,"charset":"alphanumeric","default":"GLBL"},
+       {"field":"environment","length":1,"charset":"alphanumeric","default":"P"},
+       {"field":"function","length":2,"charset":"alphanumeric","default":"SV"}]',
+     3, FALSE)
+ON CONFLICT (name, version) DO NOTHING;
+
+-- Record which scheme generated each name
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS scheme_id UUID REFERENCES naming_schemes(id);
@@ -0,0 +1,10 @@
+-- Create segment catalog table holding the allowed codes for each name segment
+CREATE TABLE IF NOT EXISTS segment_catalog (
+    segment VARCHAR(20) NOT NULL,
+    code VARCHAR(10) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    deprecated BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (segment, code)
+);
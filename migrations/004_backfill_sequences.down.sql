@@ -0,0 +1,2 @@
+-- Backfilled counters are left in place; they remain valid for MAX+1 allocation
+SELECT 1;
@@ -0,0 +1,9 @@
+-- Backfill per-key sequence counters from existing reservations so counter
+-- based allocation continues after the highest number already handed out
+INSERT INTO sequences (unit_code, type, provider, region, environment, function, current_value)
+SELECT unit_code, type, provider, region, environment, function, MAX(sequence_num)
+FROM reservations
+GROUP BY unit_code, type, provider, region, environment, function
+ON CONFLICT (unit_code, type, provider, region, environment, function)
+DO UPDATE SET current_value = GREATEST(sequences.current_value, EXCLUDED.current_value),
+              updated_at = NOW();
@@ -0,0 +1 @@
+ALTER TABLE sequences DROP COLUMN IF EXISTS overflow_policy;
@@ -0,0 +1,2 @@
+-- Per-key policy applied once a sequence runs past the numeric range of a scheme
+ALTER TABLE sequences ADD COLUMN IF NOT EXISTS overflow_policy VARCHAR(20) NOT NULL DEFAULT 'reject';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS sequence_tombstones;
+ALTER TABLE sequences DROP COLUMN IF EXISTS reuse_cooldown_seconds;
+ALTER TABLE sequences DROP COLUMN IF EXISTS allocation_mode;
+ALTER TABLE reservations DROP COLUMN IF EXISTS committed_at;
@@ -0,0 +1,20 @@
+-- Track when a reservation was committed so freed numbers can cool down
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS committed_at TIMESTAMP WITH TIME ZONE;
+UPDATE reservations SET committed_at = updated_at WHERE status = 'committed' AND committed_at IS NULL;
+
+-- Per-key allocation mode and reuse cool-down
+ALTER TABLE sequences ADD COLUMN IF NOT EXISTS allocation_mode VARCHAR(20) NOT NULL DEFAULT 'increment';
+ALTER TABLE sequences ADD COLUMN IF NOT EXISTS reuse_cooldown_seconds INTEGER NOT NULL DEFAULT 0;
+
+-- Numbers of deleted names together with when they were last committed
+CREATE TABLE IF NOT EXISTS sequence_tombstones (
+    unit_code VARCHAR(10) NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    region VARCHAR(10) NOT NULL,
+    environment VARCHAR(10) NOT NULL,
+    function VARCHAR(20) NOT NULL,
+    sequence_num INTEGER NOT NULL,
+    last_committed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (unit_code, type, provider, region, environment, function, sequence_num)
+);
@@ -0,0 +1,3 @@
+UPDATE reservations SET status = 'reserved' WHERE status = 'expired';
+DROP INDEX IF EXISTS idx_reservations_expires_at;
+ALTER TABLE reservations DROP COLUMN IF EXISTS expires_at;
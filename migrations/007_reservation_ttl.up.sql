@@ -0,0 +1,6 @@
+-- Uncommitted reservations expire after their TTL
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_reservations_expires_at
+    ON reservations (expires_at)
+    WHERE status = 'reserved';
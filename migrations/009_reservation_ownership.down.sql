@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_reservations_ticket;
+DROP INDEX IF EXISTS idx_reservations_committed_by;
+DROP INDEX IF EXISTS idx_reservations_created_by;
+ALTER TABLE reservations DROP COLUMN IF EXISTS requested_for;
+ALTER TABLE reservations DROP COLUMN IF EXISTS ticket;
+ALTER TABLE reservations DROP COLUMN IF EXISTS description;
+ALTER TABLE reservations DROP COLUMN IF EXISTS api_key_id;
+ALTER TABLE reservations DROP COLUMN IF EXISTS committed_by;
+ALTER TABLE reservations DROP COLUMN IF EXISTS created_by;
@@ -0,0 +1,11 @@
+-- Who reserved and committed a name, and why
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS created_by VARCHAR(100);
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS committed_by VARCHAR(100);
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS api_key_id VARCHAR(100);
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS ticket VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS requested_for VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_reservations_created_by ON reservations (created_by);
+CREATE INDEX IF NOT EXISTS idx_reservations_committed_by ON reservations (committed_by);
+CREATE INDEX IF NOT EXISTS idx_reservations_ticket ON reservations (ticket);
@@ -0,0 +1,4 @@
+-- Free-form key/value tags on reservations
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+CREATE INDEX IF NOT EXISTS idx_reservations_tags ON reservations USING GIN (tags);
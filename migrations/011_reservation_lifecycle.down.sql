@@ -0,0 +1,3 @@
+UPDATE reservations SET status = 'committed' WHERE status IN ('decommissioned', 'retired');
+DROP INDEX IF EXISTS idx_reservations_quarantine_until;
+ALTER TABLE reservations DROP COLUMN IF EXISTS quarantine_until;
@@ -0,0 +1,6 @@
+-- Decommissioned names are quarantined before they can be reused
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS quarantine_until TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_reservations_quarantine_until
+    ON reservations (quarantine_until)
+    WHERE status = 'decommissioned';
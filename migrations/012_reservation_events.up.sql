@@ -0,0 +1,16 @@
+-- Append-only history of every reservation change
+CREATE TABLE IF NOT EXISTS reservation_events (
+    id BIGSERIAL PRIMARY KEY,
+    reservation_id UUID NOT NULL,
+    server_name VARCHAR(100) NOT NULL,
+    event_type VARCHAR(30) NOT NULL,
+    actor VARCHAR(100),
+    api_key_id VARCHAR(100),
+    request_id VARCHAR(100),
+    before_state JSONB,
+    after_state JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Events outlive the reservations they describe, so there is no foreign key
+CREATE INDEX IF NOT EXISTS idx_reservation_events_reservation_id ON reservation_events (reservation_id, id);
@@ -0,0 +1,22 @@
+-- Transactional outbox of domain events, written in the same transaction as
+-- the reservation change and published to the configured sinks by a relay
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    aggregate_id UUID NOT NULL,
+    event_type VARCHAR(30) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    published_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Finds the oldest unpublished message of each reservation
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished
+    ON outbox (aggregate_id, id)
+    WHERE published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_published_at
+    ON outbox (published_at)
+    WHERE published_at IS NOT NULL;
@@ -0,0 +1,15 @@
+-- Expired reservations whose name was taken again cannot keep it under a
+-- table-wide unique constraint
+DELETE FROM reservations r
+WHERE r.status = 'expired'
+  AND EXISTS (
+      SELECT 1 FROM reservations o
+      WHERE o.server_name = r.server_name
+        AND o.id <> r.id
+        AND (o.status <> 'expired' OR o.created_at > r.created_at)
+  );
+
+DROP INDEX IF EXISTS idx_reservations_server_name;
+DROP INDEX IF EXISTS idx_reservations_server_name_live;
+
+ALTER TABLE reservations ADD CONSTRAINT reservations_server_name_key UNIQUE (server_name);
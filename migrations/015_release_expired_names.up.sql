@@ -0,0 +1,10 @@
+-- Expired reservations give up their names: only live reservations must hold
+-- a unique server name
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS reservations_server_name_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reservations_server_name_live
+    ON reservations (server_name)
+    WHERE status <> 'expired';
+
+CREATE INDEX IF NOT EXISTS idx_reservations_server_name
+    ON reservations (server_name);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_outbox_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished
+    ON outbox (aggregate_id, id)
+    WHERE published_at IS NULL;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS dead_lettered_at;
+ALTER TABLE outbox DROP COLUMN IF EXISTS locked_until;
@@ -0,0 +1,10 @@
+-- Outbox messages are claimed with a lease instead of row locks held while
+-- publishing, and given up on after too many failed attempts
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS dead_lettered_at TIMESTAMP WITH TIME ZONE;
+
+-- Finds the oldest pending message of each reservation
+DROP INDEX IF EXISTS idx_outbox_unpublished;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending
+    ON outbox (aggregate_id, id)
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL;
@@ -0,0 +1,4 @@
+-- Finds delivered webhook deliveries to purge once they pass retention
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_delivered_at
+    ON webhook_deliveries (delivered_at)
+    WHERE status = 'delivered';
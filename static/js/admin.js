@@ -141,6 +141,9 @@ function initializeApp() {
     loadDashboardData();
     loadReservations();
     
+    // Keep the dashboard up to date as reservations change
+    subscribeToEvents();
+    
     // Handle reservation form submission
     document.getElementById('reservationForm').addEventListener('submit', function(e) {
         e.preventDefault();
@@ -513,6 +516,78 @@ function loadDashboardData() {
     });
 }
 
+// Live reservation events, read with fetch so the auth header is sent
+let lastEventId = null;
+let eventRefreshTimer = null;
+
+function subscribeToEvents() {
+    const headers = {};
+    if (lastEventId !== null) {
+        headers['Last-Event-ID'] = lastEventId;
+    }
+    
+    fetch('/api/events/stream', { headers: headers })
+    .then(response => {
+        if (!response.ok || !response.body) {
+            throw new Error('Failed to open event stream');
+        }
+        
+        const reader = response.body.getReader();
+        const decoder = new TextDecoder();
+        let buffer = '';
+        
+        function read() {
+            return reader.read().then(({ done, value }) => {
+                if (done) {
+                    throw new Error('Event stream closed');
+                }
+                
+                buffer += decoder.decode(value, { stream: true });
+                
+                // Events are separated by a blank line
+                let boundary;
+                while ((boundary = buffer.indexOf('\n\n')) !== -1) {
+                    handleStreamEvent(buffer.slice(0, boundary));
+                    buffer = buffer.slice(boundary + 2);
+                }
+                return read();
+            });
+        }
+        return read();
+    })
+    .catch(() => {
+        // Reconnect and resume from the last event seen
+        setTimeout(subscribeToEvents, 3000);
+    });
+}
+
+function handleStreamEvent(block) {
+    let id = null;
+    let hasData = false;
+    
+    block.split('\n').forEach(line => {
+        if (line.startsWith('id:')) {
+            id = line.slice(3).trim();
+        } else if (line.startsWith('data:')) {
+            hasData = true;
+        }
+    });
+    
+    if (id !== null) {
+        lastEventId = id;
+    }
+    if (!hasData) {
+        return; // Heartbeat or retry hint
+    }
+    
+    // Coalesce bursts of events into a single refresh
+    clearTimeout(eventRefreshTimer);
+    eventRefreshTimer = setTimeout(() => {
+        loadDashboardData();
+        loadReservations();
+    }, 500);
+}
+
 // Function to update the activity chart
 function updateActivityChart(activities) {
     const ctx = document.getElementById('activityChart');
@@ -695,7 +770,7 @@ function updateRecentReservations(reservations) {
 
 // Function to load all reservations
 function loadReservations() {
-    fetch('/api/reservations')
+    fetch('/api/reservations?all=true')
     .then(response => {
         if (!response.ok) {
             throw new Error('Failed to load reservations');